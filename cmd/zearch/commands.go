@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/app"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/server"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+var (
+	errUsage     = errors.New("run zearch -h for usage")
+	errIntegrity = errors.New("data integrity problems found")
+	errNoJournal = errors.New("changes are not journaled, set a journal file with -journal")
+)
+
+// runCommand runs a non-interactive command and returns the exit code of the process
+func runCommand(name string, args []string) int {
+	var err error
+
+	switch name {
+	case "search":
+		err = searchCommand(args)
+	case "get":
+		err = getCommand(args)
+	case "fields":
+		err = fieldsCommand(args)
+	case "serve":
+		err = serveCommand(args)
+	case "check":
+		err = checkCommand(args)
+	case "stats":
+		err = statsCommand(args)
+	case "schema":
+		err = schemaCommand(args)
+	case "index":
+		err = indexCommand(args)
+	case "history":
+		err = historyCommand(args)
+	case "undo":
+		err = undoCommand(args)
+	default:
+		err = fmt.Errorf("unknown command %q, %w", name, errUsage)
+	}
+
+	return exitCode(err)
+}
+
+// searchCommand handles `zearch search <entity> --field <field> --value <value>`
+// and `zearch search <entity> --query <query>`
+func searchCommand(args []string) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("search requires an entity, %w", errUsage)
+	}
+
+	entity, err := parseEntity(args[0])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	field := fs.String("field", "", "Field to search by e.g. --field role")
+	value := fs.String("value", "", "Value the field must have e.g. --value admin")
+	match := fs.String("match", string(query.Exact), fmt.Sprintf("How --value is compared with the field, one of %v", query.Modes))
+	q := fs.String("query", "", `Query to search by e.g. --query "role:admin AND NOT verified:true"`)
+	outputFormat := formatFlag(fs)
+	page := pageFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if page.Limit < 0 || page.Offset < 0 {
+		return fmt.Errorf("--limit and --offset cannot be negative, %w", errUsage)
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	if *q != "" && *field != "" {
+		return fmt.Errorf("--query and --field cannot be used together, %w", errUsage)
+	}
+
+	if *q == "" && *field == "" {
+		return fmt.Errorf("search requires --field or --query, %w", errUsage)
+	}
+
+	mode, err := query.ParseMode(*match)
+	if err != nil {
+		return fmt.Errorf("%v, %w", err, errUsage)
+	}
+
+	c, err := newApp(*outputFormat)
+	if err != nil {
+		return err
+	}
+
+	c.SetPage(*page)
+
+	if *q != "" {
+		return c.Query(entity, *q)
+	}
+
+	return c.SearchMatch(entity, *field, *value, mode)
+}
+
+// getCommand handles `zearch get <entity> <id>`
+func getCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("get requires an entity and an ID, %w", errUsage)
+	}
+
+	entity, err := parseEntity(args[0])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	outputFormat := formatFlag(fs)
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	c, err := newApp(*outputFormat)
+	if err != nil {
+		return err
+	}
+
+	return c.Search(entity, idField(entity), args[1])
+}
+
+// idField returns the field that identifies the records of the entity
+func idField(entity string) string {
+	for _, config := range entities {
+		if config.Name == entity && config.IDField != "" {
+			return config.IDField
+		}
+	}
+
+	return "_id"
+}
+
+// fieldsCommand handles `zearch fields`
+func fieldsCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", args, errUsage)
+	}
+
+	c, err := newApp(render.Table)
+	if err != nil {
+		return err
+	}
+
+	c.PrintSearchableFields()
+
+	return nil
+}
+
+// schemaCommand handles `zearch schema`, it prints the fields found in the records of
+// every entity with their types and counts
+func schemaCommand(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	outputFormat := formatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	c, err := newApp(*outputFormat)
+	if err != nil {
+		return err
+	}
+
+	return c.PrintSchema()
+}
+
+// checkCommand handles `zearch check`, it fails when the integrity report has problems
+func checkCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", args, errUsage)
+	}
+
+	s, err := newStore()
+	if err != nil {
+		return err
+	}
+
+	report := s.Check()
+	app.New(s, os.Stdout).PrintIntegrityReport(report)
+
+	if !report.OK() {
+		return errIntegrity
+	}
+
+	return nil
+}
+
+// statsCommand handles `zearch stats <entity> --field <field>`, optionally keeping the
+// --top values, grouping timestamps by --interval or filtering the records by --query
+func statsCommand(args []string) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("stats requires an entity, %w", errUsage)
+	}
+
+	entity, err := parseEntity(args[0])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	var agg store.Aggregation
+	fs.StringVar(&agg.Field, "field", "", "Field to aggregate, can be a field of a related entity e.g. --field organization.name")
+	fs.IntVar(&agg.Top, "top", 0, "Number of most frequent values to print, 0 prints all of them")
+	fs.StringVar(&agg.Interval, "interval", "", fmt.Sprintf("Count timestamps per interval instead of per value, one of %v", store.Intervals))
+	q := fs.String("query", "", `Query selecting the records to aggregate e.g. --query "status:open"`)
+	outputFormat := formatFlag(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	if agg.Field == "" {
+		return fmt.Errorf("stats requires --field, %w", errUsage)
+	}
+
+	if agg.Top < 0 {
+		return fmt.Errorf("--top cannot be negative, %w", errUsage)
+	}
+
+	c, err := newApp(*outputFormat)
+	if err != nil {
+		return err
+	}
+
+	return c.Aggregate(entity, *q, agg)
+}
+
+// formatFlag defines the --format flag of a command, it defaults to the global -format flag
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", *format, fmt.Sprintf("Format used to print results, one of %v", render.Formats))
+}
+
+// pageFlags defines the --sort, --limit and --offset flags of a command
+func pageFlags(fs *flag.FlagSet) *app.Page {
+	page := &app.Page{}
+	fs.Var((*sortFlag)(&page.Sort), "sort", "Sort results by field[:asc|desc], can be repeated or comma separated e.g. --sort priority:desc,created_at")
+	fs.IntVar(&page.Limit, "limit", 0, "Maximum number of results to print, 0 prints all of them")
+	fs.IntVar(&page.Offset, "offset", 0, "Number of results to skip, e.g. --limit 10 --offset 20 prints the third page of 10")
+
+	return page
+}
+
+// sortFlag collects the keys of every --sort flag
+type sortFlag []app.SortKey
+
+func (f *sortFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	keys := make([]string, 0, len(*f))
+	for _, key := range *f {
+		keys = append(keys, key.String())
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func (f *sortFlag) Set(value string) error {
+	keys, err := app.ParseSort(value)
+	if err != nil {
+		return err
+	}
+
+	*f = append(*f, keys...)
+
+	return nil
+}
+
+// parseEntity accepts the singular or plural name of an entity e.g. ticket or tickets,
+// including the entities of the -entities config e.g. group or groups
+func parseEntity(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "organization", "organizations", "org", "orgs":
+		return "organizations", nil
+	case "user", "users":
+		return "users", nil
+	case "ticket", "tickets":
+		return "tickets", nil
+	}
+
+	for _, config := range entities {
+		if lower := strings.ToLower(name); config.Name == lower || config.Name == lower+"s" {
+			return config.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown entity %q, %w", name, errUsage)
+}
+
+// serveCommand handles `zearch serve --addr :8080`
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "Address the HTTP server listens on e.g. --addr :8080")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s, err := openStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(s),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s\n", *addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		log.Println("shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return srv.Shutdown(shutdownCtx)
+}
+
+// indexCommand handles `zearch index build --out data.zidx`, it loads the data files and
+// writes the index snapshot loaded on startup instead of them
+func indexCommand(args []string) error {
+	if len(args) < 1 || args[0] != "build" {
+		return fmt.Errorf("index requires the build subcommand, %w", errUsage)
+	}
+
+	fs := flag.NewFlagSet("index build", flag.ContinueOnError)
+	out := fs.String("out", *indexFilename, "File the index snapshot is written to e.g. --out data.zidx")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	s, err := buildStore()
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a snapshot is never read half written
+	tmp := *out + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = s.WriteSnapshot(w)
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write index: %w", err)
+	}
+
+	if err := os.Rename(tmp, *out); err != nil {
+		return err
+	}
+
+	log.Printf("wrote index %s\n", *out)
+
+	return nil
+}
+
+// historyCommand handles `zearch history -n 20`, it prints the last changes of the journal
+func historyCommand(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	n := fs.Int("n", 20, "Number of changes to print, the most recent first")
+	outputFormat := formatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	if *journalFilename == "" {
+		return errNoJournal
+	}
+
+	// the history is read from the journal alone, there's no need to load the data
+	c := app.New(nil, os.Stdout)
+	if err := c.SetFormat(*outputFormat); err != nil {
+		return err
+	}
+
+	setChanges(c)
+
+	return c.PrintHistory(*n)
+}
+
+// undoCommand handles `zearch undo -n 1`, it rolls back the last changes of the journal
+// and writes them back to the data files with -write
+func undoCommand(args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	n := fs.Int("n", 1, "Number of changes to undo, the most recent first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q, %w", fs.Args(), errUsage)
+	}
+
+	if *n < 1 {
+		return fmt.Errorf("-n must be a positive number, %w", errUsage)
+	}
+
+	if *journalFilename == "" {
+		return errNoJournal
+	}
+
+	c, err := newApp(render.Table)
+	if err != nil {
+		return err
+	}
+
+	setChanges(c)
+
+	return c.Undo(*n)
+}
@@ -1,32 +1,399 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/jaimem88/zearch/internal/app"
+	"github.com/jaimem88/zearch/internal/journal"
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/reader"
+	"github.com/jaimem88/zearch/internal/render"
 	"github.com/jaimem88/zearch/internal/store"
+	"github.com/jaimem88/zearch/internal/watch"
+)
+
+// Exit codes used by the non-interactive commands
+const (
+	exitFound    = 0
+	exitNotFound = 1
+	exitError    = 2
+
+	// exitProblems is returned by the check command when the data has integrity problems
+	exitProblems = 1
 )
 
 var (
 	usersFilename   = flag.String("users", "data/users.json", "Filename to load users from e.g. --users data/users.json")
-	ticketsFilename = flag.String("tickets", "data/tickets.json", "Filename to load users from e.g. --users data/users.json")
-	orgsFilename    = flag.String("organizations", "data/organizations.json", "Filename to load users from e.g. --users data/users.json")
+	ticketsFilename = flag.String("tickets", "data/tickets.json", "Filename to load tickets from e.g. --tickets data/tickets.json")
+	orgsFilename    = flag.String("organizations", "data/organizations.json", "Filename to load organizations from e.g. --organizations data/organizations.json")
+	format          = flag.String("format", render.Table, fmt.Sprintf("Format used to print results, one of %v", render.Formats))
+	stem            = flag.Bool("stem", true, "Match different forms of a word in text: searches e.g. printers matches printer")
+	skipInvalid     = flag.Bool("skip-invalid", false, "Report invalid records and continue without them instead of exiting")
+	write           = flag.Bool("write", false, "Write the records changed with Edit record in the interactive search back to the data files")
+	journalFilename = flag.String("journal", "data/journal.ndjson", "File every change to the records is journaled to, so changes can be recovered and undone, empty disables it")
+	indexFilename   = flag.String("index", "data/data.zidx", "Index snapshot loaded instead of the data files when it is newer than them, see zearch index build")
+	watchFiles      = flag.Bool("watch", false, "Reload the data files when they change, for the interactive search and serve")
+	watchInterval   = flag.Duration("watch-interval", 2*time.Second, "How often -watch checks the data files for changes")
+	entitiesFile    = flag.String("entities", "", "Config file defining more entities to load along with organizations, users and tickets e.g. --entities data/entities.json")
 )
 
+// changes is the journal of the changes to the records, nil when it is disabled or
+// there is no journal yet and the command doesn't change records
+var changes *journal.Journal
+
+// entities are the entities of the -entities config, see store.EntityConfig
+var entities []store.EntityConfig
+
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
-	data, err := model.LoadData(*orgsFilename, *usersFilename, *ticketsFilename)
+	var err error
+	entities, err = loadEntities()
+	if err != nil {
+		log.Fatalf("%+v\n", err)
+	}
+
+	changes, err = openJournal(flag.NArg() == 0)
 	if err != nil {
-		log.Fatalf("load data: %+v\n", err)
+		log.Fatalf("%+v\n", err)
 	}
 
-	c := app.New(store.New(data.Organizations, data.Users, data.Tickets), os.Stdout)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Arg(0), flag.Args()[1:]))
+	}
+
+	s, err := openStore(context.Background())
+	if err != nil {
+		log.Fatalf("%+v\n", err)
+	}
+
+	c := app.New(s, os.Stdout)
+	if err := c.SetFormat(*format); err != nil {
+		log.Fatalf("%+v\n", err)
+	}
+
+	setChanges(c)
 
 	if err := c.Run(); err != nil {
 		log.Fatalf("run: %+v\n", err)
 	}
 }
+
+// newStore loads the index snapshot when it is up to date, otherwise it streams the
+// data files into the store. The changes of the journal that were not written to the
+// data files are replayed.
+func newStore() (*store.Storage, error) {
+	s, ok := loadSnapshot()
+	if !ok {
+		var err error
+		if s, err = buildStore(); err != nil {
+			return nil, err
+		}
+	}
+
+	if changes == nil {
+		return s, nil
+	}
+
+	pending := changes.Pending()
+	if len(pending) == 0 {
+		return s, nil
+	}
+
+	applied, err := journal.Replay(s, pending)
+	if err != nil {
+		log.Printf("replay journal %s: %s\n", *journalFilename, err)
+	}
+
+	log.Printf("replayed %d of %d changes from journal %s that are not in the data files\n", applied, len(pending), *journalFilename)
+
+	return s, nil
+}
+
+// openJournal opens the journal of the changes to the records. An existing journal is
+// always opened so its changes are replayed, a new one is only created when create is true,
+// a journal that doesn't exist yet has no changes to print or undo.
+func openJournal(create bool) (*journal.Journal, error) {
+	if *journalFilename == "" {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(*journalFilename); os.IsNotExist(err) && !create {
+		return nil, nil
+	}
+
+	return journal.Open(*journalFilename)
+}
+
+// setChanges makes the App journal its changes to the records and, with -write, write
+// them back to the data files
+func setChanges(c *app.App) {
+	if changes != nil {
+		c.SetJournal(changes)
+	}
+
+	if *write {
+		files := map[string]string{}
+		for _, f := range dataFiles() {
+			files[f.entity] = f.filename
+		}
+
+		c.SetWriteBack(files)
+	}
+}
+
+// loadEntities reads the entities of the -entities config, none when it is not set
+func loadEntities() ([]store.EntityConfig, error) {
+	if *entitiesFile == "" {
+		return nil, nil
+	}
+
+	var config store.EntitiesConfig
+	if err := reader.ReadJSONFile(*entitiesFile, &config); err != nil {
+		return nil, fmt.Errorf("read entities config %s: %w", *entitiesFile, err)
+	}
+
+	return config.Entities, nil
+}
+
+// dataFile is the file the records of an entity are loaded from
+type dataFile struct {
+	entity   string
+	filename string
+}
+
+// dataFiles returns the files of organizations, users and tickets followed by the
+// files of the entities of the config
+func dataFiles() []dataFile {
+	files := []dataFile{
+		{entity: "organizations", filename: *orgsFilename},
+		{entity: "users", filename: *usersFilename},
+		{entity: "tickets", filename: *ticketsFilename},
+	}
+
+	for _, config := range entities {
+		if config.File != "" {
+			files = append(files, dataFile{entity: config.Name, filename: config.File})
+		}
+	}
+
+	return files
+}
+
+// sameEntities reports whether built is the entities config, comparing them as JSON
+// since empty and missing lists are the same in the config
+func sameEntities(built []store.EntityConfig) bool {
+	if len(built) == 0 && len(entities) == 0 {
+		return true
+	}
+
+	a, errA := json.Marshal(built)
+	b, errB := json.Marshal(entities)
+
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// loadSnapshot loads the index snapshot when it is newer than the data files and was
+// built with the same options. Snapshots that can't be read are reported and ignored,
+// so the data files are loaded instead.
+func loadSnapshot() (*store.Storage, bool) {
+	info, err := os.Stat(*indexFilename)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, f := range dataFiles() {
+		source, err := os.Stat(f.filename)
+		if err != nil {
+			log.Printf("index: %s, loading the data files\n", err)
+			return nil, false
+		}
+
+		if source.ModTime().After(info.ModTime()) {
+			log.Printf("index %s is older than %s, loading the data files\n", *indexFilename, f.filename)
+			return nil, false
+		}
+	}
+
+	f, err := os.Open(*indexFilename)
+	if err != nil {
+		log.Printf("index: %s, loading the data files\n", err)
+		return nil, false
+	}
+	defer f.Close()
+
+	s, err := store.ReadSnapshot(bufio.NewReader(f))
+	if err != nil {
+		log.Printf("index %s: %s, loading the data files, run zearch index build to rebuild it\n", *indexFilename, err)
+		return nil, false
+	}
+
+	if s.Stemming() != *stem {
+		log.Printf("index %s was built with -stem=%t, loading the data files\n", *indexFilename, s.Stemming())
+		return nil, false
+	}
+
+	if !sameEntities(s.Config()) {
+		log.Printf("index %s was built with a different entities config, loading the data files\n", *indexFilename)
+		return nil, false
+	}
+
+	return s, true
+}
+
+// buildStore streams the data files of every entity into the store
+func buildStore() (*store.Storage, error) {
+	b := store.NewBuilder()
+	b.SetStemming(*stem)
+	if err := b.SetEntities(entities); err != nil {
+		return nil, fmt.Errorf("entities config %s: %w", *entitiesFile, err)
+	}
+
+	var streams []model.Stream
+	for _, f := range dataFiles() {
+		entity := f.entity
+		streams = append(streams, model.Stream{
+			Filename: f.filename,
+			Cells:    b.CellTypes(entity),
+			Add: func(record map[string]interface{}) error {
+				return b.Add(entity, record)
+			},
+		})
+	}
+
+	err := model.StreamFiles(streams, printProgress)
+
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) && *skipInvalid {
+		log.Printf("skipping invalid records: %s\n", validationErr)
+		err = nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("load data: %w", err)
+	}
+
+	return b.Build(), nil
+}
+
+// openStore loads the data files into the store. With -watch the data files are
+// reloaded in the background whenever they change until ctx is done, and searches
+// use the new data once it's loaded.
+func openStore(ctx context.Context) (app.Storage, error) {
+	s, err := newStore()
+	if err != nil {
+		return nil, err
+	}
+
+	if !*watchFiles {
+		return s, nil
+	}
+
+	live := store.NewLive(s)
+	var files []string
+	for _, f := range dataFiles() {
+		files = append(files, f.filename)
+	}
+
+	go watch.Poll(ctx, files, *watchInterval, func() {
+		s, err := newStore()
+		if err != nil {
+			// a file may be saved with mistakes, keep searching the previous data
+			log.Printf("reload: %s, still using the previous data\n", err)
+			return
+		}
+
+		live.Swap(s)
+		log.Println("reloaded data files")
+	})
+
+	return live, nil
+}
+
+// printProgress reports the progress of loading large files to stderr so it
+// doesn't get mixed with the results
+func printProgress(p reader.Progress) {
+	if p.Done && p.Records < 100000 {
+		// small files load quickly, no need to report them
+		return
+	}
+
+	log.Printf("loading %s: %.0f%% %d records\n", p.Filename, p.Percent(), p.Records)
+}
+
+// newApp loads the data files into the store and creates an App that prints results in format
+func newApp(format string) (*app.App, error) {
+	s, err := newStore()
+	if err != nil {
+		return nil, err
+	}
+
+	c := app.New(s, os.Stdout)
+	if err := c.SetFormat(format); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: zearch [flags] [command]
+
+Runs the interactive search when no command is given. With -watch the interactive search
+and serve reload the data files when they change.
+
+Commands:
+  search <entity> --field <field> --value <value> [--match <mode>] [--format <format>] [--sort <field:asc|desc>] [--limit <n>] [--offset <n>]
+  search <entity> --query <query> [--format <format>] [--sort <field:asc|desc>] [--limit <n>] [--offset <n>]
+  get <entity> <id> [--format <format>]
+  fields
+  schema [--format <format>]
+  serve [--addr <address>]
+  check
+  index build [--out <file>]
+  history [-n <changes>] [--format <format>]
+  undo [-n <changes>]
+  stats <entity> --field <field> [--top <n>] [--interval day|week|month] [--query <query>] [--format <format>]
+
+Entities are organizations, users, tickets and the ones defined with -entities.
+Commands exit with status %d when results are found, %d when nothing is found and %d on
+errors. check exits with status %d when it finds duplicate IDs or references to records
+that don't exist.
+
+Data files can be .json, .ndjson, .jsonl or .csv and may be gzipped e.g. users.ndjson.gz
+
+Flags:
+`, exitFound, exitNotFound, exitError, exitProblems)
+	flag.PrintDefaults()
+}
+
+// exitCode maps the error returned by a command to the exit status of the process
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitFound
+	case errors.Is(err, store.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, errIntegrity):
+		return exitProblems
+	default:
+		var syntaxErr *query.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			fmt.Fprintln(os.Stderr, syntaxErr.Pointer())
+		}
+
+		fmt.Fprintf(os.Stderr, "zearch: %s\n", err)
+		return exitError
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+// setFlag sets a flag for the duration of the test
+func setFlag(t *testing.T, flag *string, value string) {
+	previous := *flag
+	t.Cleanup(func() { *flag = previous })
+	*flag = value
+}
+
+func TestNewStore_OlderSnapshotVersion(t *testing.T) {
+	dir := t.TempDir()
+	setFlag(t, orgsFilename, filepath.Join(dir, "organizations.json"))
+	setFlag(t, usersFilename, filepath.Join(dir, "users.json"))
+	setFlag(t, ticketsFilename, filepath.Join(dir, "tickets.json"))
+	setFlag(t, indexFilename, filepath.Join(dir, "data.zidx"))
+	setFlag(t, journalFilename, "")
+
+	require.NoError(t, os.WriteFile(*orgsFilename, []byte(`[{"_id": 101, "name": "Enthaze"}]`), 0o644))
+	require.NoError(t, os.WriteFile(*usersFilename, []byte(`[{"_id": 1, "name": "Francis Bailey"}]`), 0o644))
+	require.NoError(t, os.WriteFile(*ticketsFilename, []byte(`[]`), 0o644))
+
+	// a snapshot newer than the data files, written in the format before the entities config
+	var buf bytes.Buffer
+	require.NoError(t, store.New(nil, model.Users{{"_id": float64(2), "name": "Cross Barlow"}}, nil).WriteSnapshot(&buf))
+	snapshot := buf.Bytes()
+	snapshot[7] = 1
+	require.NoError(t, os.WriteFile(*indexFilename, snapshot, 0o644))
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(*indexFilename, later, later))
+
+	_, ok := loadSnapshot()
+	assert.False(t, ok)
+
+	s, err := newStore()
+	require.NoError(t, err)
+
+	_, err = s.Search("users", query.Term{Field: "name", Value: "Francis Bailey"})
+	assert.NoError(t, err)
+	_, err = s.Search("users", query.Term{Field: "name", Value: "Cross Barlow"})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
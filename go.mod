@@ -5,4 +5,5 @@ go 1.16
 require (
 	github.com/manifoldco/promptui v0.8.0
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
@@ -4,12 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"strconv"
 	"strings"
 
 	"github.com/manifoldco/promptui"
 
+	"github.com/jaimem88/zearch/internal/journal"
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/render"
 	"github.com/jaimem88/zearch/internal/store"
 )
 
@@ -22,29 +25,68 @@ var (
 )
 
 // Storage defines the methods that the App store requires in order to get
-// the Organizations, Users and Tickets from the underlying storage.
+// the Organizations, Users, Tickets and the entities of the config from the
+// underlying storage.
 type Storage interface {
-	Organizations(term, value string) ([]model.OrganizationResult, error)
-	Users(term, value string) ([]model.UserResult, error)
-	Tickets(term, value string) ([]model.TicketResult, error)
+	// Search finds the records of any entity, see store.Storage.Search
+	Search(entity string, q query.Expr) ([]model.Result, error)
+	// Entities describes organizations, users, tickets and the entities of the config
+	Entities() []store.EntityConfig
 	GetSearchableFields() map[string][]string
+	// Suggest returns values of the field close to value, used when nothing is found
+	Suggest(entity, field, value string) []string
+	Aggregate(entity string, agg store.Aggregation) (store.Stats, error)
+	Schema() map[string][]store.FieldSchema
+	// Record, Insert, Update and Delete read and change a single record, see store.Storage
+	Record(entity, id string) (map[string]interface{}, bool)
+	Records(entity string) ([]map[string]interface{}, error)
+	Insert(entity string, record map[string]interface{}) error
+	Update(entity string, record map[string]interface{}) error
+	Delete(entity, id string) error
 }
 
 // App handles the CLI interaction with the user and does the
 // information presentation to stdout
 type App struct {
-	store Storage
-	out   io.Writer
+	store    Storage
+	out      io.Writer
+	format   string
+	renderer render.Renderer
+	page     Page
+	// files are the data files changes are written back to, see SetWriteBack
+	files   map[string]string
+	journal *journal.Journal
 }
 
-// New creates an App with the defined Storage
+// New creates an App with the defined Storage. Results are rendered as a table
+// unless a different format is set with SetFormat.
 func New(store Storage, out io.Writer) *App {
 	return &App{
-		store: store,
-		out:   out,
+		store:    store,
+		out:      out,
+		format:   render.Table,
+		renderer: &render.TableRenderer{},
 	}
 }
 
+// SetFormat changes the format used to render search results, see render.Formats
+func (a *App) SetFormat(format string) error {
+	renderer, err := render.New(format)
+	if err != nil {
+		return err
+	}
+
+	a.format = format
+	a.renderer = renderer
+
+	return nil
+}
+
+// SetPage changes how search results are sorted and paginated
+func (a *App) SetPage(page Page) {
+	a.page = page
+}
+
 // Run the App and handle user input vua promptui
 func (a *App) Run() error {
 	welcomePrompt := promptui.Prompt{
@@ -59,47 +101,59 @@ func (a *App) Run() error {
 		return err
 	}
 
+	actions := []menuAction{
+		{name: "Zearch Zendesk", run: a.handleSearch, failed: "search failed"},
+		{name: "Zearch with a query", run: a.handleQuery, failed: "query failed"},
+		{name: "View searchable fields", run: func() error {
+			a.PrintSearchableFields()
+			return nil
+		}},
+		{name: "Sort and paginate results", run: a.handleSortAndPage, failed: "sort and paginate failed"},
+		{name: "Aggregate", run: a.handleAggregate, failed: "aggregate failed"},
+		{name: "Edit record", run: a.handleEdit, failed: "edit failed"},
+	}
+
+	items := make([]string, 0, len(actions)+1)
+	for _, action := range actions {
+		items = append(items, action.name)
+	}
+
 	actionPrompt := promptui.Select{
 		Label:     "What would you like to do?",
-		Items:     []string{"Zearch Zendesk", "View searchable fields", "Quit"},
+		Items:     append(items, "Quit"),
 		Templates: selectTemplate,
 	}
 
-	stop := false
-	for !stop {
+	for {
 		n, _, err := actionPrompt.Run()
 		if err != nil {
 			return err
 		}
 
-		switch n {
-		case 0:
-			if err := a.handleSearch(); err != nil {
-				return fmt.Errorf("search failed: %w", err)
-			}
-		case 1:
-			a.printSearchableFields()
-		case 2:
-			stop, err = a.handleQuit()
-			if err != nil {
+		if n == len(actions) {
+			stop, err := a.handleQuit()
+			if err != nil || stop {
 				return err
 			}
-		default:
-			return fmt.Errorf("unknown option: %d", n)
+
+			continue
+		}
+
+		if err := actions[n].run(); err != nil {
+			return fmt.Errorf("%s: %w", actions[n].failed, err)
 		}
 	}
+}
 
-	return nil
+// menuAction is an option of the menu of Run, failed describes the errors of run
+type menuAction struct {
+	name   string
+	run    func() error
+	failed string
 }
 
 func (a *App) handleSearch() error {
-	selectEntity := promptui.Select{
-		Label:     "Select a search option:",
-		Items:     []string{"Users", "Tickets", "Organizations"},
-		Templates: selectTemplate,
-	}
-
-	_, entity, err := selectEntity.Run()
+	entity, err := a.selectEntity()
 	if err != nil {
 		return err
 	}
@@ -122,7 +176,174 @@ func (a *App) handleSearch() error {
 		return err
 	}
 
-	return a.Search(entity, term, value)
+	mode, err := a.selectMode()
+	if err != nil {
+		return err
+	}
+
+	q := query.Term{Field: term, Value: value, Mode: mode}
+	if _, err := q.Matcher(); err != nil {
+		// let the user try again instead of quitting the app
+		fmt.Fprintln(a.out, err)
+		return nil
+	}
+
+	err = a.browse(entity, q)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (a *App) selectEntity() (string, error) {
+	items := []string{"Users", "Tickets", "Organizations"}
+	for _, config := range a.store.Entities() {
+		if title := entityTitle(config.Name); !contains(items, title) {
+			items = append(items, title)
+		}
+	}
+
+	selectEntity := promptui.Select{
+		Label:     "Select a search option:",
+		Items:     items,
+		Templates: selectTemplate,
+	}
+
+	_, entity, err := selectEntity.Run()
+
+	return entity, err
+}
+
+// selectMode asks how the search value is compared with the values of the field
+func (a *App) selectMode() (query.Mode, error) {
+	selectMode := promptui.Select{
+		Label:     "Select match mode:",
+		Items:     query.Modes,
+		Templates: selectTemplate,
+	}
+
+	k, _, err := selectMode.Run()
+	if err != nil {
+		return "", err
+	}
+
+	return query.Modes[k], nil
+}
+
+func (a *App) handleQuery() error {
+	entity, err := a.selectEntity()
+	if err != nil {
+		return err
+	}
+
+	promptQuery := promptui.Prompt{
+		Label: "Type query e.g. status:open AND (priority:high OR priority:urgent)",
+	}
+
+	input, err := promptQuery.Run()
+	if err != nil {
+		return err
+	}
+
+	q, err := query.Parse(input)
+	if err == nil {
+		err = a.browse(entity, q)
+	}
+
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// let the user try again instead of quitting the app
+		fmt.Fprintf(a.out, "%s\n%s\n", syntaxErr.Pointer(), syntaxErr)
+		return nil
+	}
+
+	return err
+}
+
+// handleSortAndPage asks for the sort keys and the page size used by the following searches
+func (a *App) handleSortAndPage() error {
+	promptSort := promptui.Prompt{
+		Label:     "Sort by e.g. priority:desc,created_at (empty to keep the loaded order)",
+		Default:   formatSort(a.page.Sort),
+		AllowEdit: true,
+		Validate: func(input string) error {
+			_, err := ParseSort(input)
+			return err
+		},
+	}
+
+	input, err := promptSort.Run()
+	if err != nil {
+		return err
+	}
+
+	// validated by the prompt
+	keys, _ := ParseSort(input)
+
+	promptLimit := promptui.Prompt{
+		Label:     "Results per page (0 to show all)",
+		Default:   strconv.Itoa(a.page.Limit),
+		AllowEdit: true,
+		Validate: func(input string) error {
+			if limit, err := strconv.Atoi(input); err != nil || limit < 0 {
+				return errors.New("must be a positive number or 0")
+			}
+
+			return nil
+		},
+	}
+
+	input, err = promptLimit.Run()
+	if err != nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(input)
+	a.page = Page{Sort: keys, Limit: limit}
+
+	return nil
+}
+
+func formatSort(keys []SortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key.String())
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// browse prints the results of the query one page at a time, asking before
+// printing the next page
+func (a *App) browse(entity string, q query.Expr) error {
+	page := a.page
+	for {
+		next, more, err := a.searchPage(entity, q, page)
+		if err != nil || !more {
+			return err
+		}
+
+		confirmNext := promptui.Prompt{
+			Label:     "Show next page",
+			IsConfirm: true,
+		}
+
+		answer, err := confirmNext.Run()
+		if err != nil && !errors.Is(err, promptui.ErrAbort) {
+			return err
+		}
+
+		if strings.ToLower(answer) != "y" {
+			return nil
+		}
+
+		page.Offset = next
+	}
 }
 
 func (a *App) handleQuit() (bool, error) {
@@ -146,116 +367,133 @@ func (a *App) handleQuit() (bool, error) {
 	return false, nil
 }
 
+// Search the entity for the records where term has value and print them.
+// Returns store.ErrNotFound when there are no results.
 func (a *App) Search(entity, term string, value string) error {
-	a.printDashes(80)
-
-	switch strings.ToLower(entity) {
-	case "organizations":
-		return a.searchOrganizations(term, value)
-	case "users":
-		return a.searchUsers(term, value)
-	case "tickets":
-		return a.searchTickets(term, value)
-	default:
-		return fmt.Errorf("unkoown entity: %s", entity)
-	}
+	return a.SearchMatch(entity, term, value, query.Exact)
 }
 
-func (a *App) searchOrganizations(term, value string) error {
-	var orgResults []model.OrganizationResult
-	// TODO: handle `and` properly
-	terms := strings.Split(term, " or ")
-	values := strings.Split(value, " or ")
+// SearchMatch is like Search but compares value with the values of term using mode,
+// e.g. query.Prefix finds the records where term starts with value.
+func (a *App) SearchMatch(entity, term, value string, mode query.Mode) error {
+	q := query.Term{Field: term, Value: value, Mode: mode}
+	if _, err := q.Matcher(); err != nil {
+		return err
+	}
+
+	return a.search(entity, q)
+}
 
-	if len(terms) != len(values) {
-		return fmt.Errorf("%d terms do not match %d values", len(terms), len(values))
+// Query parses the input query and prints the records of the entity that match it.
+// Returns store.ErrNotFound when there are no results.
+func (a *App) Query(entity, input string) error {
+	q, err := query.Parse(input)
+	if err != nil {
+		return err
 	}
 
-	for k, term := range terms {
-		orgResultsSubset, err := a.store.Organizations(term, values[k])
-		if err != nil {
-			if errors.Is(err, store.ErrNotFound) {
-				fmt.Fprintf(a.out, "No results found")
-				return nil
-			}
+	return a.search(entity, q)
+}
 
-			return err
-		}
+func (a *App) search(entity string, q query.Expr) error {
+	_, _, err := a.searchPage(entity, q, a.page)
+	return err
+}
+
+// searchPage prints the results of the query within page. Returns the offset of the
+// next page and whether there is one.
+func (a *App) searchPage(entity string, q query.Expr, page Page) (int, bool, error) {
+	entity = strings.ToLower(entity)
 
-		orgResults = append(orgResults, orgResultsSubset...)
+	results, err := a.store.Search(entity, q)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return 0, false, err
 	}
 
-	for _, orgResult := range orgResults {
-		err := model.OrgResultTemplate.Execute(a.out, orgResult)
-		if err != nil {
-			return err
+	total := len(results)
+	results = page.Apply(results)
+	if err == nil && len(results) == 0 {
+		// the offset is past the last result
+		err = store.ErrNotFound
+	}
+
+	if a.format == render.Table {
+		a.printDashes(80)
+		fmt.Fprintf(a.out, "Searching %s by: %s\n", entity, q)
+		if len(page.Sort) > 0 {
+			fmt.Fprintf(a.out, "Sorted by: %s\n", formatSort(page.Sort))
 		}
 	}
 
-	fmt.Fprintf(a.out, "Total organizations found: %d\n", len(orgResults))
+	if table, ok := a.renderer.(*render.TableRenderer); ok {
+		table.Total = total
+	}
 
-	return nil
-}
-func (a *App) searchUsers(term, value string) error {
-	userResults, err := a.store.Users(term, value)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			fmt.Println("No results found")
-			return nil
-		}
+	if renderErr := a.renderer.Render(a.out, entity, results); renderErr != nil {
+		return 0, false, renderErr
+	}
 
-		return err
+	if a.format == render.Table && len(results) > 0 && len(results) < total {
+		fmt.Fprintf(a.out, "Showing %s %d-%d of %d\n", entity, page.Offset+1, page.Offset+len(results), total)
 	}
 
-	for _, userResult := range userResults {
-		err := model.UserResultTemplate.Execute(os.Stdout, userResult)
-		if err != nil {
-			return err
+	if a.format == render.Table && total == 0 {
+		if suggestions := Suggestions(a.store, entity, q); len(suggestions) > 0 {
+			fmt.Fprintf(a.out, "Did you mean %s?\n", strings.Join(suggestions, " or "))
 		}
 	}
 
-	fmt.Fprintf(a.out, "Total users found: %d\n", len(userResults))
+	next, more := page.Next(total)
 
-	return nil
+	return next, more, err
 }
 
-func (a *App) searchTickets(term, value string) error {
-	ticketResults, err := a.store.Tickets(term, value)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			fmt.Println("No results found")
-			return nil
-		}
-
-		return err
+// Suggestions returns the queries to suggest when q doesn't find any entity. Only
+// exact searches by a single field get suggestions, e.g. name:"Fransis Bailey"
+// suggests name:"Francis Bailey".
+func Suggestions(s Storage, entity string, q query.Expr) []string {
+	term, ok := q.(query.Term)
+	if !ok || (term.Mode != "" && term.Mode != query.Exact) {
+		return nil
 	}
 
-	for _, ticketResult := range ticketResults {
-		err := model.TicketResultTemplate.Execute(os.Stdout, ticketResult)
-		if err != nil {
-			return err
-		}
+	var suggestions []string
+	for _, value := range s.Suggest(entity, term.Field, term.Value) {
+		suggestions = append(suggestions, query.Term{Field: term.Field, Value: value}.String())
 	}
 
-	fmt.Fprintf(a.out, "Total tickets found: %d\n", len(ticketResults))
-
-	return nil
+	return suggestions
 }
 
-func (a *App) printSearchableFields() {
-	a.printDashes(80)
-	fields := a.store.GetSearchableFields()
-	a.printFields("Organizations", fields["organizations"])
+// entityTitle returns the name of the entity as it is shown in menus and headings,
+// the entity is the title in lower case
+func entityTitle(entity string) string {
+	if entity == "" {
+		return entity
+	}
+
+	return strings.ToUpper(entity[:1]) + entity[1:]
+}
 
-	a.printDashes(80)
-	a.printFields("Users", fields["users"])
+// entityConfig returns the description of the entity, see store.Storage.Entities
+func (a *App) entityConfig(entity string) store.EntityConfig {
+	for _, config := range a.store.Entities() {
+		if config.Name == entity {
+			return config
+		}
+	}
 
-	a.printDashes(80)
-	a.printFields("Tickets", fields["tickets"])
+	return store.EntityConfig{Name: entity, IDField: "_id", IDType: model.IDNumber}
 }
 
-func (a *App) printFields(param string, fields []string) {
-	fmt.Fprintf(a.out, "Search %s by:\n%s", param, strings.Join(fields, "\n"))
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (a *App) printDashes(n int) {
@@ -5,14 +5,18 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/store"
 )
 
 func TestSearch_ByOrganization(t *testing.T) {
 	buf := &bytes.Buffer{}
 	app := New(&mockStore{
-		orgResults: []model.OrganizationResult{
-			{
+		results: []model.Result{
+			model.OrganizationResult{
 				Organization: model.Organization{
 					"_id":         125,
 					"url":         "http://initech.zendesk.com/api/v2/organizations/125.json",
@@ -35,7 +39,7 @@ func TestSearch_ByOrganization(t *testing.T) {
 					},
 				},
 			},
-			{
+			model.OrganizationResult{
 				Organization: model.Organization{
 					"_id":         124,
 					"url":         "http://initech.zendesk.com/api/v2/organizations/124.json",
@@ -62,7 +66,7 @@ func TestSearch_ByOrganization(t *testing.T) {
 	},
 		buf)
 
-	err := app.Search("organizations", "name or name", "Bitrex or Strezzö")
+	err := app.Query("organizations", `name:Bitrex OR name:Strezzö`)
 	if err != nil {
 		t.Errorf("%+v", err)
 	}
@@ -76,33 +80,162 @@ func TestSearch_ByOrganization(t *testing.T) {
 		t.Error("output does not contain name")
 	}
 
-	err = app.Search("organizations", "_id or name", "Strezzö")
+	err = app.Query("organizations", "_id:125 OR Strezzö")
 	if err == nil {
 		t.Fatal("expected error but got nil")
 	}
 
-	if !(err.Error() == "2 terms do not match 1 values") {
+	if !(err.Error() == `syntax error at position 12 near "Strezzö": expected field:value, values containing spaces must be quoted`) {
 		t.Fatalf("expected error message does not match: %q", err)
 	}
 }
 
-type mockStore struct {
-	orgResults []model.OrganizationResult
-	err        error
+func TestSearch_NotFound(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{err: store.ErrNotFound}, buf)
+
+	err := app.Search("organizations", "name", "Initech")
+	require.ErrorIs(t, err, store.ErrNotFound)
+	require.Contains(t, buf.String(), "No results found")
+}
+
+func TestSearch_Suggestions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{err: store.ErrNotFound, suggestions: []string{"Enthaze", "Zentix Ltd"}}, buf)
+
+	require.ErrorIs(t, app.Search("organizations", "name", "Entaze"), store.ErrNotFound)
+	require.Contains(t, buf.String(), `Did you mean name:Enthaze or name:"Zentix Ltd"?`)
+
+	buf.Reset()
+	require.ErrorIs(t, app.SearchMatch("organizations", "name", "Entaze", query.Prefix), store.ErrNotFound)
+	require.NotContains(t, buf.String(), "Did you mean")
+}
+
+func TestSearchMatch(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{results: []model.Result{model.OrganizationResult{Organization: model.Organization{"_id": 101, "name": "Enthaze"}}}}, buf)
+
+	require.NoError(t, app.SearchMatch("organizations", "name", "Ent", query.Prefix))
+	require.Contains(t, buf.String(), "Searching organizations by: name:prefix:Ent")
+
+	err := app.SearchMatch("organizations", "name", "(", query.Regex)
+	require.EqualError(t, err, "invalid regular expression: error parsing regexp: missing closing ): `(`")
+}
+
+func TestPrintIntegrityReport(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{}, buf)
+
+	app.PrintIntegrityReport(store.IntegrityReport{
+		Records:    map[string]int{"organizations": 1, "users": 2, "tickets": 3},
+		Duplicates: []store.Duplicate{{Entity: "tickets", ID: "a", Count: 2}},
+		DanglingReferences: []store.DanglingReference{
+			{Entity: "users", ID: "2", Field: "organization_id", Target: "organizations", Value: "999"},
+		},
+	})
+
+	expected := `Records: 1 organizations, 2 users, 3 tickets
+
+Duplicate IDs, only the last record is kept:
+  tickets a loaded 2 times
+
+Dangling references:
+  users 2: organization_id 999 not found in organizations
+
+Summary:
+  duplicate IDs:                     1
+  users with missing organization:   1
+  tickets with missing organization: 0
+  tickets with missing submitter:    0
+  tickets with missing assignee:     0
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestPrintIntegrityReport_Entities(t *testing.T) {
+	buf := &bytes.Buffer{}
+	entities := append(store.NewBuilder().Build().Entities(), store.EntityConfig{
+		Name:      "groups",
+		Relations: []store.RelationConfig{{Name: "organization", Link: "organization_id -> organizations._id"}},
+	})
+	app := New(&mockStore{entities: entities}, buf)
+
+	app.PrintIntegrityReport(store.IntegrityReport{
+		Records: map[string]int{"organizations": 1, "users": 2, "tickets": 3, "groups": 4},
+		DanglingReferences: []store.DanglingReference{
+			{Entity: "groups", ID: "2", Field: "organization_id", Target: "organizations", Value: "999"},
+		},
+	})
+
+	expected := `Records: 1 organizations, 2 users, 3 tickets, 4 groups
+
+Dangling references:
+  groups 2: organization_id 999 not found in organizations
+
+Summary:
+  duplicate IDs:                     0
+  users with missing organization:   0
+  tickets with missing organization: 0
+  tickets with missing submitter:    0
+  tickets with missing assignee:     0
+  groups with missing organization:  1
+`
+	require.Equal(t, expected, buf.String())
 }
 
-func (ms *mockStore) Organizations(term, value string) ([]model.OrganizationResult, error) {
-	return ms.orgResults, ms.err
+type mockStore struct {
+	err         error
+	suggestions []string
+	stats       store.Stats
+	schema      map[string][]store.FieldSchema
+	results     []model.Result
+	entities    []store.EntityConfig
 }
 
-func (ms *mockStore) Users(term, value string) ([]model.UserResult, error) {
-	return nil, nil
+func (ms *mockStore) Search(entity string, q query.Expr) ([]model.Result, error) {
+	return ms.results, ms.err
 }
 
-func (ms *mockStore) Tickets(term, value string) ([]model.TicketResult, error) {
-	return nil, nil
+func (ms *mockStore) Entities() []store.EntityConfig {
+	if ms.entities == nil {
+		return store.NewBuilder().Build().Entities()
+	}
+
+	return ms.entities
 }
 
 func (ms *mockStore) GetSearchableFields() map[string][]string {
 	return nil
 }
+
+func (ms *mockStore) Suggest(entity, field, value string) []string {
+	return ms.suggestions
+}
+
+func (ms *mockStore) Schema() map[string][]store.FieldSchema {
+	return ms.schema
+}
+
+func (ms *mockStore) Record(entity, id string) (map[string]interface{}, bool) {
+	return nil, false
+}
+
+func (ms *mockStore) Records(entity string) ([]map[string]interface{}, error) {
+	return nil, ms.err
+}
+
+func (ms *mockStore) Insert(entity string, record map[string]interface{}) error {
+	return ms.err
+}
+
+func (ms *mockStore) Update(entity string, record map[string]interface{}) error {
+	return ms.err
+}
+
+func (ms *mockStore) Delete(entity, id string) error {
+	return ms.err
+}
+
+func (ms *mockStore) Aggregate(entity string, agg store.Aggregation) (store.Stats, error) {
+	return ms.stats, ms.err
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+// PrintIntegrityReport prints the problems found by store.Storage.Check followed by
+// a summary with the number of problems of each kind
+func (a *App) PrintIntegrityReport(report store.IntegrityReport) {
+	counts := make([]string, 0, len(report.Records))
+	for _, config := range a.store.Entities() {
+		counts = append(counts, fmt.Sprintf("%d %s", report.Records[config.Name], config.Name))
+	}
+
+	fmt.Fprintf(a.out, "Records: %s\n", strings.Join(counts, ", "))
+
+	if len(report.Duplicates) > 0 {
+		fmt.Fprintln(a.out, "\nDuplicate IDs, only the last record is kept:")
+		for _, d := range report.Duplicates {
+			fmt.Fprintf(a.out, "  %s %s loaded %d times\n", d.Entity, d.ID, d.Count)
+		}
+	}
+
+	if len(report.DanglingReferences) > 0 {
+		fmt.Fprintln(a.out, "\nDangling references:")
+		for _, ref := range report.DanglingReferences {
+			fmt.Fprintf(a.out, "  %s %s: %s %s not found in %s\n", ref.Entity, ref.ID, ref.Field, ref.Value, ref.Target)
+		}
+	}
+
+	fmt.Fprintln(a.out, "\nSummary:")
+	w := tabwriter.NewWriter(a.out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "  duplicate IDs:\t%d\n", len(report.Duplicates))
+	for _, config := range a.store.Entities() {
+		for _, rc := range config.Relations {
+			fmt.Fprintf(w, "  %s with missing %s:\t%d\n", config.Name, rc.Name, report.Count(config.Name, rc.Field()))
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,331 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/jaimem88/zearch/internal/journal"
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/reader"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+// SetWriteBack writes the records of an entity back to its data file after every change,
+// files maps every entity to its data file. Changes are only kept in memory otherwise.
+func (a *App) SetWriteBack(files map[string]string) {
+	a.files = files
+}
+
+// SetJournal records every change in j before it is written back to the data files, so
+// changes can be replayed after a crash and undone
+func (a *App) SetJournal(j *journal.Journal) {
+	a.journal = j
+}
+
+// InsertRecord adds a new record to the entity and writes it back to its data file
+func (a *App) InsertRecord(entity string, record map[string]interface{}) error {
+	entity = strings.ToLower(entity)
+	return a.change(entity, a.recordID(entity, record), func() error {
+		return a.store.Insert(entity, record)
+	})
+}
+
+// UpdateRecord replaces the record of the entity with the same ID and writes it back to
+// its data file
+func (a *App) UpdateRecord(entity string, record map[string]interface{}) error {
+	entity = strings.ToLower(entity)
+	return a.change(entity, a.recordID(entity, record), func() error {
+		return a.store.Update(entity, record)
+	})
+}
+
+// DeleteRecord removes the record of the entity with the ID and writes the remaining
+// records back to its data file
+func (a *App) DeleteRecord(entity, id string) error {
+	entity = strings.ToLower(entity)
+	return a.change(entity, id, func() error {
+		return a.store.Delete(entity, id)
+	})
+}
+
+// change applies a change to the record of the entity with the ID and records it
+func (a *App) change(entity, id string, apply func() error) error {
+	before, _ := a.store.Record(entity, id)
+	if err := apply(); err != nil {
+		return err
+	}
+
+	after, _ := a.store.Record(entity, id)
+
+	return a.record(journal.Entry{
+		Op:     journal.OpOf(before, after),
+		Entity: entity,
+		ID:     id,
+		Before: before,
+		After:  after,
+	})
+}
+
+// record journals a change that was applied to the store and writes it back to the
+// data file of the entity
+func (a *App) record(change journal.Entry) error {
+	if a.journal != nil {
+		if _, err := a.journal.Append(change); err != nil {
+			// a change that is not journaled can't be recovered or undone, roll it back
+			_ = journal.Apply(a.store, change.Entity, change.ID, change.Before)
+			return fmt.Errorf("journal: %w", err)
+		}
+	}
+
+	return a.writeBack(change.Entity)
+}
+
+// writeBack writes the records of the entity to its data file and marks its changes
+// as committed in the journal
+func (a *App) writeBack(entity string) error {
+	filename, ok := a.files[entity]
+	if !ok {
+		return nil
+	}
+
+	records, err := a.store.Records(entity)
+	if err != nil {
+		return err
+	}
+
+	if err := reader.WriteFile(filename, records); err != nil {
+		return err
+	}
+
+	if a.journal != nil {
+		return a.journal.Commit(entity)
+	}
+
+	return nil
+}
+
+// recordID returns the ID of the record of the entity as it is written in queries
+func (a *App) recordID(entity string, record map[string]interface{}) string {
+	switch id := record[a.entityConfig(entity).IDField].(type) {
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	case string:
+		return id
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+// recordEdit is a record being edited by handleEdit
+type recordEdit struct {
+	entity string
+	config store.EntityConfig
+	id     string
+	// record is the record with the changes made so far
+	record map[string]interface{}
+	exists bool
+}
+
+// handleEdit asks for the ID of a record and lets the user set and remove its fields,
+// or delete it. A new ID creates a record. Mistakes are printed so the user can fix them.
+func (a *App) handleEdit() error {
+	entity, err := a.selectEntity()
+	if err != nil {
+		return err
+	}
+
+	entity = strings.ToLower(entity)
+	config := a.entityConfig(entity)
+
+	promptID := promptui.Prompt{
+		Label: fmt.Sprintf("Type %s of the record to edit, or a new %s to create one", config.IDField, config.IDField),
+	}
+
+	id, err := promptID.Run()
+	if err != nil {
+		return err
+	}
+
+	edit := a.newRecordEdit(entity, config, id)
+	if edit.exists {
+		if err := a.search(entity, query.Term{Field: config.IDField, Value: id}); err != nil {
+			return err
+		}
+	}
+
+	actions := []string{"Set field", "Remove field", "Save", "Cancel"}
+	if edit.exists {
+		actions = append(actions, "Delete record")
+	}
+
+	selectAction := promptui.Select{
+		Label:     "Select what to do with the record:",
+		Items:     actions,
+		Templates: selectTemplate,
+	}
+
+	for {
+		_, action, err := selectAction.Run()
+		if err != nil {
+			return err
+		}
+
+		done, err := a.editRecord(edit, action)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// newRecordEdit starts editing the record of the entity with the ID, a new record
+// with the ID when there is none
+func (a *App) newRecordEdit(entity string, config store.EntityConfig, id string) *recordEdit {
+	record, exists := a.store.Record(entity, id)
+	edit := &recordEdit{
+		entity: entity,
+		config: config,
+		id:     id,
+		record: map[string]interface{}{config.IDField: parseValue(id)},
+		exists: exists,
+	}
+
+	if config.IDType == model.IDString {
+		edit.record[config.IDField] = id
+	}
+
+	for field, v := range record {
+		edit.record[field] = v
+	}
+
+	return edit
+}
+
+// editRecord does the action selected for the record. Returns whether the edit is done.
+func (a *App) editRecord(edit *recordEdit, action string) (bool, error) {
+	switch action {
+	case "Set field":
+		return false, a.setField(edit)
+	case "Remove field":
+		return false, a.removeField(edit)
+	case "Save":
+		return a.saveRecord(edit)
+	case "Delete record":
+		return true, a.deleteRecord(edit)
+	default:
+		return true, nil
+	}
+}
+
+// setField asks for a field and its value and sets it
+func (a *App) setField(edit *recordEdit) error {
+	field, value, err := promptFieldValue()
+	if err != nil {
+		return err
+	}
+
+	if err := checkEditable(edit.config, field); err != nil {
+		fmt.Fprintln(a.out, err)
+		return nil
+	}
+
+	edit.record[field] = value
+
+	return nil
+}
+
+// removeField asks for a field and removes it
+func (a *App) removeField(edit *recordEdit) error {
+	promptField := promptui.Prompt{Label: "Type field to remove"}
+	field, err := promptField.Run()
+	if err != nil {
+		return err
+	}
+
+	if err := checkEditable(edit.config, field); err != nil {
+		fmt.Fprintln(a.out, err)
+		return nil
+	}
+
+	delete(edit.record, field)
+
+	return nil
+}
+
+// saveRecord inserts or updates the record and prints it. Returns whether it was saved.
+func (a *App) saveRecord(edit *recordEdit) (bool, error) {
+	var err error
+	if edit.exists {
+		err = a.UpdateRecord(edit.entity, edit.record)
+	} else {
+		err = a.InsertRecord(edit.entity, edit.record)
+	}
+
+	if err != nil {
+		// let the user fix the record or cancel instead of quitting the app and
+		// losing the changes
+		fmt.Fprintln(a.out, err)
+		return false, nil
+	}
+
+	return true, a.search(edit.entity, query.Term{Field: edit.config.IDField, Value: edit.id})
+}
+
+// deleteRecord deletes the record
+func (a *App) deleteRecord(edit *recordEdit) error {
+	if err := a.DeleteRecord(edit.entity, edit.id); err != nil {
+		// the record may have been deleted since it was looked up
+		fmt.Fprintln(a.out, err)
+		return nil
+	}
+
+	fmt.Fprintf(a.out, "Deleted %s %s\n", edit.entity, edit.id)
+
+	return nil
+}
+
+// checkEditable returns an error when the field is the ID of the entity. Changing it
+// would make the record a different one, so it is created and the old one deleted instead.
+func checkEditable(config store.EntityConfig, field string) error {
+	if field == config.IDField {
+		return fmt.Errorf("%s is the ID of the record and can't be changed, create a record with the new %s and delete this one instead", field, field)
+	}
+
+	return nil
+}
+
+// promptFieldValue asks for a field and the value to set it to
+func promptFieldValue() (string, interface{}, error) {
+	promptField := promptui.Prompt{Label: "Type field to set e.g. assignee_id"}
+	field, err := promptField.Run()
+	if err != nil {
+		return "", nil, err
+	}
+
+	promptValue := promptui.Prompt{
+		Label: `Type value, JSON values like 101, true, null or ["West"] keep their type`,
+	}
+
+	value, err := promptValue.Run()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return field, parseValue(value), nil
+}
+
+// parseValue converts a value typed by the user into the type it would have in a JSON
+// file, e.g. 101 is a number and "101" a string. Anything that is not valid JSON is a
+// string.
+func parseValue(value string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err == nil {
+		return v
+	}
+
+	return value
+}
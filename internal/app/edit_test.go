@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestApp_EditRecords(t *testing.T) {
+	s := store.New(nil, nil, model.Tickets{
+		{"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": float64(1)},
+	})
+
+	filename := filepath.Join(t.TempDir(), "tickets.json")
+	app := New(s, &bytes.Buffer{})
+	app.SetWriteBack(map[string]string{"tickets": filename})
+
+	require.NoError(t, app.UpdateRecord("Tickets", map[string]interface{}{
+		"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": float64(2),
+	}))
+	require.NoError(t, app.InsertRecord("tickets", map[string]interface{}{
+		"_id": "7382ad0e", "subject": "A Nuisance in Kiribati", "tags": []interface{}{"Ohio"},
+	}))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": 2},
+		{"_id": "7382ad0e", "subject": "A Nuisance in Kiribati", "tags": ["Ohio"]}
+	]`, string(content))
+
+	require.NoError(t, app.DeleteRecord("tickets", "27c447d9"))
+
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"_id": "7382ad0e", "subject": "A Nuisance in Kiribati", "tags": ["Ohio"]}]`, string(content))
+
+	// the file is not written when the change fails
+	err = app.InsertRecord("tickets", map[string]interface{}{"_id": "7382ad0e"})
+	require.ErrorIs(t, err, store.ErrExists)
+	err = app.DeleteRecord("tickets", "27c447d9")
+	require.ErrorIs(t, err, store.ErrNotFound)
+
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"_id": "7382ad0e", "subject": "A Nuisance in Kiribati", "tags": ["Ohio"]}]`, string(content))
+}
+
+func TestCheckEditable(t *testing.T) {
+	config := store.EntityConfig{Name: "tickets", IDField: "_id", IDType: model.IDString}
+
+	assert.NoError(t, checkEditable(config, "subject"))
+	assert.EqualError(t, checkEditable(config, "_id"),
+		"_id is the ID of the record and can't be changed, create a record with the new _id and delete this one instead")
+}
+
+func TestParseValue(t *testing.T) {
+	assert.Equal(t, float64(101), parseValue("101"))
+	assert.Equal(t, "101", parseValue(`"101"`))
+	assert.Equal(t, true, parseValue("true"))
+	assert.Nil(t, parseValue("null"))
+	assert.Equal(t, []interface{}{"West"}, parseValue(`["West"]`))
+	assert.Equal(t, "Francis Bailey", parseValue("Francis Bailey"))
+}
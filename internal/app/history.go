@@ -0,0 +1,124 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/journal"
+	"github.com/jaimem88/zearch/internal/render"
+)
+
+// Undo rolls back the last n changes, the most recent first. Undoing a change is a
+// change too, it is journaled and written back to the data files like any other. There
+// is nothing to undo without a journal.
+func (a *App) Undo(n int) error {
+	var changes []journal.Entry
+	if a.journal != nil {
+		changes = a.journal.Undoable(n)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(a.out, "Nothing to undo")
+		return nil
+	}
+
+	for _, change := range changes {
+		before, _ := a.store.Record(change.Entity, change.ID)
+		if err := journal.Apply(a.store, change.Entity, change.ID, change.Before); err != nil {
+			return fmt.Errorf("undo change %d: %w", change.Seq, err)
+		}
+
+		after, _ := a.store.Record(change.Entity, change.ID)
+		err := a.record(journal.Entry{
+			Op:     journal.OpOf(before, after),
+			Entity: change.Entity,
+			ID:     change.ID,
+			Before: before,
+			After:  after,
+			Undoes: change.Seq,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(a.out, "Undid change %d: %s %s %s\n", change.Seq, change.Op, change.Entity, change.ID)
+	}
+
+	return nil
+}
+
+// PrintHistory prints the last n changes of the journal, the most recent first, along
+// with the fields they changed and whether they are written to the data files. There are
+// no changes without a journal.
+func (a *App) PrintHistory(n int) error {
+	var entries []journal.Entry
+	pending := map[int]bool{}
+	if a.journal != nil {
+		entries = a.journal.Entries()
+		for _, change := range a.journal.Pending() {
+			pending[change.Seq] = true
+		}
+	}
+
+	undoneBy := map[int]int{}
+	var changes []journal.Entry
+	for k := len(entries) - 1; k >= 0; k-- {
+		e := entries[k]
+		if e.Undoes > 0 {
+			undoneBy[e.Undoes] = e.Seq
+		}
+
+		if !e.Commit && len(changes) < n {
+			changes = append(changes, e)
+		}
+	}
+
+	status := func(change journal.Entry) string {
+		s := "written"
+		if pending[change.Seq] {
+			s = "pending"
+		}
+
+		if change.Undoes > 0 {
+			s += fmt.Sprintf(", undoes %d", change.Undoes)
+		}
+
+		if seq, ok := undoneBy[change.Seq]; ok {
+			s += fmt.Sprintf(", undone by %d", seq)
+		}
+
+		return s
+	}
+
+	if a.format != render.Table {
+		rows := [][]string{{"seq", "time", "op", "entity", "id", "fields", "status"}}
+		for _, change := range changes {
+			rows = append(rows, []string{
+				strconv.Itoa(change.Seq), change.Time.Format(time.RFC3339), change.Op, change.Entity, change.ID,
+				strings.Join(change.Fields(), " "), status(change),
+			})
+		}
+
+		return render.Encode(a.out, a.format, changes, rows)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(a.out, "No changes")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SEQ\tTIME\tOP\tENTITY\tID\tFIELDS\tSTATUS")
+	for _, change := range changes {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", change.Seq, change.Time.Local().Format(timeFormat),
+			change.Op, change.Entity, change.ID, strings.Join(change.Fields(), ", "), status(change))
+	}
+
+	return w.Flush()
+}
+
+// timeFormat is how the time of the changes is printed
+const timeFormat = "2006-01-02 15:04:05"
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/journal"
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestApp_Undo(t *testing.T) {
+	dir := t.TempDir()
+	j, err := journal.Open(filepath.Join(dir, "journal.ndjson"))
+	require.NoError(t, err)
+	defer j.Close()
+
+	s := store.New(nil, nil, model.Tickets{
+		{"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": float64(1)},
+	})
+
+	buf := &bytes.Buffer{}
+	app := New(s, buf)
+	app.SetJournal(j)
+
+	require.NoError(t, app.UpdateRecord("tickets", map[string]interface{}{
+		"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": float64(2),
+	}))
+
+	// only changes written back are committed
+	filename := filepath.Join(dir, "tickets.json")
+	app.SetWriteBack(map[string]string{"tickets": filename})
+	require.NoError(t, app.InsertRecord("tickets", map[string]interface{}{"_id": "7382ad0e", "subject": "A Nuisance in Kiribati"}))
+	require.NoError(t, app.DeleteRecord("tickets", "27c447d9"))
+	assert.Empty(t, j.Pending())
+
+	require.NoError(t, app.Undo(2))
+	assert.Equal(t, "Undid change 4: delete tickets 27c447d9\nUndid change 2: insert tickets 7382ad0e\n", buf.String())
+
+	records, err := s.Records("tickets")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": float64(2)},
+	}, records)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"_id": "27c447d9", "subject": "A Problem in Guyana", "assignee_id": 2}]`, string(content))
+
+	buf.Reset()
+	require.NoError(t, app.PrintHistory(10))
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 6)
+	assert.Regexp(t, `^SEQ +TIME +OP +ENTITY +ID +FIELDS +STATUS$`, string(lines[0]))
+	assert.Regexp(t, `^8 .* delete +tickets +7382ad0e +_id, subject +written, undoes 2$`, string(lines[1]))
+	assert.Regexp(t, `^6 .* insert +tickets +27c447d9 +_id, assignee_id, subject +written, undoes 4$`, string(lines[2]))
+	assert.Regexp(t, `^4 .* delete +tickets +27c447d9 +_id, assignee_id, subject +written, undone by 6$`, string(lines[3]))
+	assert.Regexp(t, `^2 .* insert +tickets +7382ad0e +_id, subject +written, undone by 8$`, string(lines[4]))
+	assert.Regexp(t, `^1 .* update +tickets +27c447d9 +assignee_id +written$`, string(lines[5]))
+
+	// undoing again rolls back the change before
+	buf.Reset()
+	require.NoError(t, app.Undo(5))
+	assert.Equal(t, "Undid change 1: update tickets 27c447d9\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, app.Undo(1))
+	assert.Equal(t, "Nothing to undo\n", buf.String())
+
+	require.NoError(t, app.SetFormat(render.CSV))
+	buf.Reset()
+	require.NoError(t, app.PrintHistory(1))
+	assert.Regexp(t, "^seq,time,op,entity,id,fields,status\n10,.*,update,tickets,27c447d9,assignee_id,\"written, undoes 1\"\n$", buf.String())
+}
+
+func TestApp_UndoWithoutJournal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{}, buf)
+
+	require.NoError(t, app.Undo(1))
+	assert.Equal(t, "Nothing to undo\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, app.PrintHistory(1))
+	assert.Equal(t, "No changes\n", buf.String())
+}
@@ -0,0 +1,269 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// SortKey sorts results by the value of Field, which can be any field of the record
+// or of its related entities e.g. organization_name
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the key as it is written in --sort, e.g. name:desc
+func (k SortKey) String() string {
+	if k.Desc {
+		return k.Field + ":desc"
+	}
+
+	return k.Field + ":asc"
+}
+
+// ParseSort parses a comma separated list of sort keys written as field[:asc|desc],
+// e.g. "priority:desc,created_at". Keys are ascending by default.
+func ParseSort(input string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := SortKey{Field: part}
+		if i := strings.LastIndex(part, ":"); i >= 0 {
+			key.Field = part[:i]
+
+			switch strings.ToLower(part[i+1:]) {
+			case "asc":
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q in %q, must be asc or desc", part[i+1:], part)
+			}
+		}
+
+		if key.Field == "" {
+			return nil, fmt.Errorf("missing field name in sort key %q", part)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Page selects the order of the results and which of them are shown. Results keep
+// the order returned by the store when there are no sort keys, and all of them are
+// shown when Limit is zero.
+type Page struct {
+	Sort   []SortKey
+	Limit  int
+	Offset int
+}
+
+// Apply sorts the results and returns the ones within the page
+func (p Page) Apply(results []model.Result) []model.Result {
+	if len(p.Sort) > 0 {
+		results = SortResults(results, p.Sort)
+	}
+
+	if p.Offset >= len(results) {
+		return nil
+	}
+
+	end := len(results)
+	if p.Limit > 0 && p.Offset+p.Limit < end {
+		end = p.Offset + p.Limit
+	}
+
+	return results[p.Offset:end]
+}
+
+// Next returns the offset of the page after this one, false when this is the last page
+// out of total results
+func (p Page) Next(total int) (int, bool) {
+	if p.Limit <= 0 || p.Offset+p.Limit >= total {
+		return 0, false
+	}
+
+	return p.Offset + p.Limit, true
+}
+
+// SortResults returns a copy of results sorted by keys, the first key that is
+// different between two results decides their order. Records missing a field always
+// go last and results that are equal for every key keep their original order.
+// The values of the keys are read and parsed once per result before sorting.
+func SortResults(results []model.Result, keys []SortKey) []model.Result {
+	values := make([][]sortValue, len(results))
+	for k, result := range results {
+		values[k] = make([]sortValue, len(keys))
+		for i, key := range keys {
+			values[k][i] = newSortValue(fieldValue(result, key.Field))
+		}
+	}
+
+	order := make([]int, len(results))
+	for k := range order {
+		order[k] = k
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := values[order[i]], values[order[j]]
+		for k, key := range keys {
+			if c := compareSortValues(a[k], b[k], key.Desc); c != 0 {
+				return c < 0
+			}
+		}
+
+		return false
+	})
+
+	sorted := make([]model.Result, 0, len(results))
+	for _, k := range order {
+		sorted = append(sorted, results[k])
+	}
+
+	return sorted
+}
+
+// fieldValue returns the value of the field of the result like render.Record does,
+// related fields take precedence over the fields of the record
+func fieldValue(result model.Result, field string) interface{} {
+	for _, related := range result.Related() {
+		if related.Name == field {
+			return related.Value
+		}
+	}
+
+	return result.Fields()[field]
+}
+
+// sortValue is a value of a field parsed to be compared: numbers and booleans by
+// number, timestamps in model.TimeLayout chronologically, other strings alphabetically
+// and arrays element by element. Values of different types are ordered by rank.
+type sortValue struct {
+	missing bool
+	rank    int
+	number  float64
+	text    string
+	time    time.Time
+	isTime  bool
+	elems   []sortValue
+}
+
+func newSortValue(v interface{}) sortValue {
+	switch v := v.(type) {
+	case nil:
+		return sortValue{missing: true}
+	case bool:
+		sv := sortValue{rank: 0}
+		if v {
+			sv.number = 1
+		}
+
+		return sv
+	case float64:
+		return sortValue{rank: 1, number: v}
+	case string:
+		sv := sortValue{rank: 2, text: v}
+		if t, err := time.Parse(model.TimeLayout, v); err == nil {
+			sv.time, sv.isTime = t, true
+		}
+
+		return sv
+	default:
+		elems := elements(v)
+		sv := sortValue{rank: 3, elems: make([]sortValue, 0, len(elems))}
+		for _, elem := range elems {
+			sv.elems = append(sv.elems, newSortValue(elem))
+		}
+
+		return sv
+	}
+}
+
+// compareSortValues compares two values of a field, missing and null values go last
+// regardless of the direction
+func compareSortValues(a, b sortValue, desc bool) int {
+	switch {
+	case a.missing && b.missing:
+		return 0
+	case a.missing:
+		return 1
+	case b.missing:
+		return -1
+	}
+
+	c := a.compare(b)
+	if desc {
+		return -c
+	}
+
+	return c
+}
+
+func (a sortValue) compare(b sortValue) int {
+	if a.rank != b.rank {
+		return a.rank - b.rank
+	}
+
+	switch a.rank {
+	case 0, 1:
+		return compareFloats(a.number, b.number)
+	case 2:
+		if a.isTime && b.isTime {
+			switch {
+			case a.time.Before(b.time):
+				return -1
+			case a.time.After(b.time):
+				return 1
+			default:
+				return 0
+			}
+		}
+
+		return strings.Compare(a.text, b.text)
+	default:
+		for k := 0; k < len(a.elems) && k < len(b.elems); k++ {
+			if c := compareSortValues(a.elems[k], b.elems[k], false); c != 0 {
+				return c
+			}
+		}
+
+		return len(a.elems) - len(b.elems)
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// elements returns the elements of an array field, related fields hold []string
+func elements(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		elems := make([]interface{}, 0, len(v))
+		for _, s := range v {
+			elems = append(elems, s)
+		}
+
+		return elems
+	default:
+		return []interface{}{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      []SortKey
+		expectedError string
+	}{
+		{
+			name:     "default_ascending",
+			input:    "name",
+			expected: []SortKey{{Field: "name"}},
+		},
+		{
+			name:     "multiple_keys",
+			input:    "priority:DESC, created_at:asc",
+			expected: []SortKey{{Field: "priority", Desc: true}, {Field: "created_at"}},
+		},
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:          "invalid_direction",
+			input:         "name:up",
+			expectedError: `invalid sort direction "up" in "name:up", must be asc or desc`,
+		},
+		{
+			name:          "missing_field",
+			input:         ":desc",
+			expectedError: `missing field name in sort key ":desc"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseSort(tt.input)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, keys)
+		})
+	}
+}
+
+func TestSortResults(t *testing.T) {
+	results := []model.Result{
+		model.TicketResult{Ticket: model.Ticket{"_id": "a", "priority": "high", "due_at": "2016-08-15T05:37:32 -10:00", "has_incidents": true}},
+		model.TicketResult{Ticket: model.Ticket{"_id": "b", "priority": "low", "due_at": "2016-08-15T05:37:32 +10:00", "has_incidents": false}},
+		model.TicketResult{Ticket: model.Ticket{"_id": "c", "priority": "high", "has_incidents": false}, AssigneeName: "Zed"},
+		model.TicketResult{Ticket: model.Ticket{"_id": "d", "priority": "high", "due_at": "2016-07-31T02:37:50 -10:00"}, AssigneeName: "Ann"},
+	}
+
+	tests := []struct {
+		name     string
+		sort     string
+		expected []string
+	}{
+		{
+			name:     "timestamps_chronologically_missing_last",
+			sort:     "due_at",
+			expected: []string{"d", "b", "a", "c"},
+		},
+		{
+			name:     "descending_keeps_missing_last",
+			sort:     "due_at:desc",
+			expected: []string{"a", "b", "d", "c"},
+		},
+		{
+			name:     "booleans_false_first_ties_keep_order",
+			sort:     "has_incidents",
+			expected: []string{"b", "c", "a", "d"},
+		},
+		{
+			name:     "multiple_keys_and_related_fields",
+			sort:     "priority:asc,assignee_name:desc",
+			expected: []string{"c", "d", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseSort(tt.sort)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, result := range SortResults(results, keys) {
+				ids = append(ids, result.Fields()["_id"].(string))
+			}
+
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestSortValue_Compare(t *testing.T) {
+	compareValues := func(a, b interface{}) int {
+		return newSortValue(a).compare(newSortValue(b))
+	}
+
+	assert.Negative(t, compareValues(float64(2), float64(10)))
+	assert.Negative(t, compareValues(false, true))
+	assert.Negative(t, compareValues("Bitrex", "Strezzö"))
+	assert.Negative(t, compareValues([]interface{}{"a", "b"}, []interface{}{"a", "c"}))
+	assert.Negative(t, compareValues([]string{"a"}, []string{"a", "b"}))
+	assert.Negative(t, compareValues(float64(1), "1"), "numbers sort before strings")
+	assert.Zero(t, compareValues("2016-04-28T11:19:34 -10:00", "2016-04-29T07:19:34 +10:00"))
+}
+
+func TestPage_Apply(t *testing.T) {
+	var results []model.Result
+	for _, id := range []float64{1, 2, 3, 4, 5} {
+		results = append(results, model.UserResult{User: model.User{"_id": id}})
+	}
+
+	page := Page{Sort: []SortKey{{Field: "_id", Desc: true}}, Limit: 2, Offset: 2}
+	assert.Equal(t, []model.Result{results[2], results[1]}, page.Apply(results))
+
+	next, ok := page.Next(len(results))
+	assert.True(t, ok)
+	assert.Equal(t, 4, next)
+
+	_, ok = Page{Limit: 2, Offset: 4}.Next(len(results))
+	assert.False(t, ok)
+
+	assert.Empty(t, Page{Offset: 5}.Apply(results))
+	assert.Equal(t, results, Page{}.Apply(results))
+}
+
+func TestSearch_Page(t *testing.T) {
+	buf := &bytes.Buffer{}
+	app := New(&mockStore{
+		results: []model.Result{
+			model.OrganizationResult{Organization: model.Organization{"_id": float64(101), "name": "Enthaze"}},
+			model.OrganizationResult{Organization: model.Organization{"_id": float64(102), "name": "Bitrex"}},
+			model.OrganizationResult{Organization: model.Organization{"_id": float64(103), "name": "Strezzö"}},
+		},
+	}, buf)
+	app.SetPage(Page{Sort: []SortKey{{Field: "name"}}, Limit: 2})
+
+	require.NoError(t, app.Search("organizations", "details", "MegaCorp"))
+
+	out := buf.String()
+	assert.Contains(t, out, "Sorted by: name:asc\n")
+	assert.Contains(t, out, "Showing organizations 1-2 of 3\n")
+	assert.Contains(t, out, "Total organizations found: 3\n")
+	assert.Less(t, strings.Index(out, "Bitrex"), strings.Index(out, "Enthaze"))
+	assert.NotContains(t, out, "Strezzö")
+
+	app.SetPage(Page{Offset: 3})
+	require.ErrorIs(t, app.Search("organizations", "details", "MegaCorp"), store.ErrNotFound)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jaimem88/zearch/internal/render"
+)
+
+// PrintSchema prints the fields of every entity with their JSON types and how many
+// records have them in the format of the App, see PrintSearchableFields for tables
+func (a *App) PrintSchema() error {
+	schema := a.store.Schema()
+
+	if a.format == render.Table {
+		a.PrintSearchableFields()
+		return nil
+	}
+
+	rows := [][]string{{"entity", "field", "types", "records", "nulls", "missing", "distinct"}}
+	for _, config := range a.store.Entities() {
+		for _, f := range schema[config.Name] {
+			rows = append(rows, []string{config.Name, f.Name, strings.Join(f.Types, "|"), strconv.Itoa(f.Records),
+				strconv.Itoa(f.Nulls), strconv.Itoa(f.Missing), strconv.Itoa(f.Distinct)})
+		}
+	}
+
+	return render.Encode(a.out, a.format, schema, rows)
+}
+
+// PrintSearchableFields prints the fields that can be used to search each entity,
+// which are the fields found in any of its records, along with their JSON types, how
+// many records have a null value or are missing the field and how many distinct values
+// it has
+func (a *App) PrintSearchableFields() {
+	schema := a.store.Schema()
+	for _, config := range a.store.Entities() {
+		a.printDashes(80)
+		fmt.Fprintf(a.out, "Search %s by:\n", entityTitle(config.Name))
+
+		w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FIELD\tTYPES\tRECORDS\tNULL\tMISSING\tDISTINCT")
+		for _, f := range schema[config.Name] {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", f.Name, strings.Join(f.Types, ", "), f.Records, f.Nulls, f.Missing, f.Distinct)
+		}
+
+		w.Flush()
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestPrintSchema(t *testing.T) {
+	schema := map[string][]store.FieldSchema{
+		"users": {
+			{Name: "_id", Types: []string{"number"}, Records: 3, Distinct: 3},
+			{Name: "alias", Types: []string{"null", "string"}, Records: 2, Nulls: 1, Missing: 1, Distinct: 1},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: render.Table,
+			expected: `
+--------------------------------------------------------------------------------
+Search Organizations by:
+FIELD  TYPES  RECORDS  NULL  MISSING  DISTINCT
+
+--------------------------------------------------------------------------------
+Search Users by:
+FIELD  TYPES         RECORDS  NULL  MISSING  DISTINCT
+_id    number        3        0     0        3
+alias  null, string  2        1     1        1
+
+--------------------------------------------------------------------------------
+Search Tickets by:
+FIELD  TYPES  RECORDS  NULL  MISSING  DISTINCT
+`,
+		},
+		{
+			format:   render.CSV,
+			expected: "entity,field,types,records,nulls,missing,distinct\nusers,_id,number,3,0,0,3\nusers,alias,null|string,2,1,1,1\n",
+		},
+		{
+			format:   render.NDJSON,
+			expected: `{"users":[{"name":"_id","types":["number"],"records":3,"nulls":0,"missing":0,"distinct":3},{"name":"alias","types":["null","string"],"records":2,"nulls":1,"missing":1,"distinct":1}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			app := New(&mockStore{schema: schema}, buf)
+			require.NoError(t, app.SetFormat(tt.format))
+
+			require.NoError(t, app.PrintSchema())
+			require.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
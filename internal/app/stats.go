@@ -0,0 +1,151 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+// Aggregate computes the statistics of a field of the entity and prints them. input
+// is an optional query that selects the records aggregated.
+func (a *App) Aggregate(entity, input string, agg store.Aggregation) error {
+	if input != "" {
+		q, err := query.Parse(input)
+		if err != nil {
+			return err
+		}
+
+		agg.Query = q
+	}
+
+	stats, err := a.store.Aggregate(strings.ToLower(entity), agg)
+	if err != nil {
+		return err
+	}
+
+	return a.PrintStats(stats)
+}
+
+// PrintStats prints the statistics in the format of the App. Tables show the share of
+// the records of every bucket and CSV only has the buckets.
+func (a *App) PrintStats(stats store.Stats) error {
+	if a.format != render.Table {
+		rows := [][]string{{stats.Field, "count"}}
+		for _, bucket := range stats.Buckets {
+			rows = append(rows, []string{bucket.Value, strconv.Itoa(bucket.Count)})
+		}
+
+		return render.Encode(a.out, a.format, stats, rows)
+	}
+
+	a.printDashes(80)
+	title := fmt.Sprintf("%s by %s", stats.Entity, stats.Field)
+	if stats.Interval != "" {
+		title += " per " + stats.Interval
+	}
+
+	fmt.Fprintln(a.out, title)
+	fmt.Fprintf(a.out, "Records: %d, missing %s: %d, distinct values: %d\n", stats.Records, stats.Field, stats.Missing, stats.Distinct)
+
+	if len(stats.Buckets) > 0 {
+		fmt.Fprintln(a.out)
+		w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+		for _, bucket := range stats.Buckets {
+			fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", bucket.Value, bucket.Count, percent(bucket.Count, stats.Records))
+		}
+
+		w.Flush()
+	}
+
+	if n := stats.Numbers; n != nil {
+		fmt.Fprintf(a.out, "\nNumbers: %d, min %s, max %s, avg %s, sum %s\n",
+			n.Count, formatNumber(n.Min), formatNumber(n.Max), formatNumber(n.Avg), formatNumber(n.Sum))
+	}
+
+	return nil
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(n) * 100 / float64(total)
+}
+
+// formatNumber prints whole numbers without decimals and rounds the rest to 3 decimals
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*1000)/1000, 'f', -1, 64)
+}
+
+// handleAggregate asks for the entity, the field and how to aggregate it, and an
+// optional query to select the records
+func (a *App) handleAggregate() error {
+	entity, err := a.selectEntity()
+	if err != nil {
+		return err
+	}
+
+	promptField := promptui.Prompt{
+		Label: "Type field to aggregate e.g. status or organization.name:",
+	}
+
+	field, err := promptField.Run()
+	if err != nil {
+		return err
+	}
+
+	agg := store.Aggregation{Field: field}
+
+	selectKind := promptui.Select{
+		Label:     "Select aggregation:",
+		Items:     []string{"Count every value", "Top 10 values", "Per day", "Per week", "Per month"},
+		Templates: selectTemplate,
+	}
+
+	n, _, err := selectKind.Run()
+	if err != nil {
+		return err
+	}
+
+	switch n {
+	case 1:
+		agg.Top = 10
+	case 2, 3, 4:
+		agg.Interval = store.Intervals[n-2]
+	}
+
+	promptQuery := promptui.Prompt{
+		Label: "Type query to filter the records (empty to aggregate all of them)",
+	}
+
+	input, err := promptQuery.Run()
+	if err != nil {
+		return err
+	}
+
+	err = a.Aggregate(entity, input, agg)
+
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// let the user try again instead of quitting the app
+		fmt.Fprintf(a.out, "%s\n%s\n", syntaxErr.Pointer(), syntaxErr)
+		return nil
+	}
+
+	if err != nil {
+		// unknown fields, relations and intervals are mistakes the user can fix too
+		fmt.Fprintln(a.out, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestPrintStats(t *testing.T) {
+	stats := store.Stats{
+		Entity:   "tickets",
+		Field:    "organization_id",
+		Records:  4,
+		Missing:  1,
+		Distinct: 2,
+		Buckets:  []store.Bucket{{Value: "101", Count: 2}, {Value: "102", Count: 1}},
+		Numbers:  &store.NumberStats{Count: 3, Min: 101, Max: 102, Sum: 304, Avg: 304.0 / 3},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: render.Table,
+			expected: `
+--------------------------------------------------------------------------------
+tickets by organization_id
+Records: 4, missing organization_id: 1, distinct values: 2
+
+101  2  50.0%
+102  1  25.0%
+
+Numbers: 3, min 101, max 102, avg 101.333, sum 304
+`,
+		},
+		{
+			format:   render.NDJSON,
+			expected: `{"entity":"tickets","field":"organization_id","records":4,"missing":1,"distinct":2,"buckets":[{"value":"101","count":2},{"value":"102","count":1}],"numbers":{"count":3,"min":101,"max":102,"sum":304,"avg":101.33333333333333}}` + "\n",
+		},
+		{
+			format:   render.CSV,
+			expected: "organization_id,count\n101,2\n102,1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			app := New(&mockStore{stats: stats}, buf)
+			require.NoError(t, app.SetFormat(tt.format))
+
+			require.NoError(t, app.Aggregate("Tickets", "", store.Aggregation{Field: "organization_id"}))
+			require.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	app := New(&mockStore{}, &bytes.Buffer{})
+	err := app.Aggregate("tickets", "status:open AND", store.Aggregation{Field: "priority"})
+	require.EqualError(t, err, `syntax error at position 16 near "end of query": unexpected end of query, expected field:value`)
+}
@@ -0,0 +1,267 @@
+// Package journal keeps an append-only log of the changes made to the records of the
+// store, so changes that were not written back to the data files can be replayed after
+// a restart or a crash, and any change can be undone.
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations of the changes
+const (
+	Insert = "insert"
+	Update = "update"
+	Delete = "delete"
+)
+
+// Entry is a line of the journal. A change has the record before and after it, Before
+// is nil for inserts and After for deletes. A commit marks that the changes of Entity
+// journaled before it are in its data file and don't need to be replayed.
+type Entry struct {
+	Seq    int                    `json:"seq"`
+	Time   time.Time              `json:"time"`
+	Op     string                 `json:"op,omitempty"`
+	Entity string                 `json:"entity"`
+	ID     string                 `json:"id,omitempty"`
+	Before map[string]interface{} `json:"before,omitempty"`
+	After  map[string]interface{} `json:"after,omitempty"`
+	// Undoes is the Seq of the change rolled back by this one
+	Undoes int  `json:"undoes,omitempty"`
+	Commit bool `json:"commit,omitempty"`
+}
+
+// Fields returns the sorted names of the fields that are different before and after
+// the change
+func (e Entry) Fields() []string {
+	var fields []string
+	for field, v := range e.Before {
+		if after, ok := e.After[field]; !ok || !reflect.DeepEqual(v, after) {
+			fields = append(fields, field)
+		}
+	}
+
+	for field := range e.After {
+		if _, ok := e.Before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+// OpOf returns the operation that changes before into after
+func OpOf(before, after map[string]interface{}) string {
+	switch {
+	case before == nil:
+		return Insert
+	case after == nil:
+		return Delete
+	default:
+		return Update
+	}
+}
+
+// Store is where the changes are replayed and undone, see store.Storage
+type Store interface {
+	Record(entity, id string) (map[string]interface{}, bool)
+	Insert(entity string, record map[string]interface{}) error
+	Update(entity string, record map[string]interface{}) error
+	Delete(entity, id string) error
+}
+
+// Journal appends entries to a newline delimited JSON file. Every entry is synced to
+// disk before Append returns. It is safe for concurrent use.
+type Journal struct {
+	m       sync.Mutex
+	f       *os.File
+	entries []Entry
+	// now returns the time of new entries, replaced in tests
+	now func() time.Time
+}
+
+// Open reads the entries of the journal in filename, creating it if it doesn't exist.
+// A last line that was not completely written, e.g. because of a crash, is discarded.
+func Open(filename string) (*Journal, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{f: f, now: time.Now}
+	if err := j.read(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read journal %s: %w", filename, err)
+	}
+
+	return j, nil
+}
+
+func (j *Journal) read() error {
+	content, err := io.ReadAll(j.f)
+	if err != nil {
+		return err
+	}
+
+	complete := bytes.LastIndexByte(content, '\n') + 1
+	for k, line := range bytes.Split(content[:complete], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("line %d: %w", k+1, err)
+		}
+
+		j.entries = append(j.entries, e)
+	}
+
+	if complete < len(content) {
+		if err := j.f.Truncate(int64(complete)); err != nil {
+			return err
+		}
+	}
+
+	_, err = j.f.Seek(int64(complete), io.SeekStart)
+	return err
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// Entries returns every entry of the journal in the order they were appended
+func (j *Journal) Entries() []Entry {
+	j.m.Lock()
+	defer j.m.Unlock()
+
+	return append([]Entry(nil), j.entries...)
+}
+
+// Append writes a change to the journal, setting its Seq and Time
+func (j *Journal) Append(e Entry) (Entry, error) {
+	j.m.Lock()
+	defer j.m.Unlock()
+
+	e.Seq = len(j.entries) + 1
+	if len(j.entries) > 0 {
+		e.Seq = j.entries[len(j.entries)-1].Seq + 1
+	}
+
+	e.Time = j.now().UTC()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return e, err
+	}
+
+	if err := j.f.Sync(); err != nil {
+		return e, err
+	}
+
+	j.entries = append(j.entries, e)
+
+	return e, nil
+}
+
+// Commit marks that the changes of the entity are written to its data file
+func (j *Journal) Commit(entity string) error {
+	_, err := j.Append(Entry{Entity: entity, Commit: true})
+	return err
+}
+
+// Pending returns the changes that are not committed, in the order they were made
+func (j *Journal) Pending() []Entry {
+	entries := j.Entries()
+
+	committed := map[string]int{}
+	for _, e := range entries {
+		if e.Commit {
+			committed[e.Entity] = e.Seq
+		}
+	}
+
+	var pending []Entry
+	for _, e := range entries {
+		if !e.Commit && e.Seq > committed[e.Entity] {
+			pending = append(pending, e)
+		}
+	}
+
+	return pending
+}
+
+// Undoable returns the last n changes that can be undone, the most recent first.
+// Changes that undo others and changes that were undone can't be undone.
+func (j *Journal) Undoable(n int) []Entry {
+	entries := j.Entries()
+
+	undone := map[int]bool{}
+	for _, e := range entries {
+		if e.Undoes > 0 {
+			undone[e.Undoes] = true
+		}
+	}
+
+	var changes []Entry
+	for k := len(entries) - 1; k >= 0 && len(changes) < n; k-- {
+		e := entries[k]
+		if !e.Commit && e.Undoes == 0 && !undone[e.Seq] {
+			changes = append(changes, e)
+		}
+	}
+
+	return changes
+}
+
+// Replay applies the changes to s, see Apply. Changes that can't be applied, e.g.
+// because the data files were edited since, are skipped and returned in the error.
+// It returns the number of changes applied.
+func Replay(s Store, changes []Entry) (int, error) {
+	var failed []string
+	for _, e := range changes {
+		if err := Apply(s, e.Entity, e.ID, e.After); err != nil {
+			failed = append(failed, fmt.Sprintf("change %d: %s", e.Seq, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return len(changes) - len(failed), errors.New(strings.Join(failed, "; "))
+	}
+
+	return len(changes), nil
+}
+
+// Apply makes the record of the entity with the ID be record, inserting or updating
+// it, or deletes it when record is nil
+func Apply(s Store, entity, id string, record map[string]interface{}) error {
+	_, exists := s.Record(entity, id)
+
+	switch {
+	case record == nil && exists:
+		return s.Delete(entity, id)
+	case record == nil:
+		return nil
+	case exists:
+		return s.Update(entity, record)
+	default:
+		return s.Insert(entity, record)
+	}
+}
@@ -0,0 +1,105 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func openJournal(t *testing.T, filename string) *Journal {
+	t.Helper()
+
+	j, err := Open(filename)
+	require.NoError(t, err)
+	t.Cleanup(func() { j.Close() })
+
+	j.now = func() time.Time { return time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC) }
+
+	return j
+}
+
+func TestJournal(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "journal.ndjson")
+	j := openJournal(t, filename)
+
+	first, err := j.Append(Entry{Op: Insert, Entity: "users", ID: "1", After: map[string]interface{}{"_id": float64(1)}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Seq)
+	require.NoError(t, j.Commit("users"))
+	_, err = j.Append(Entry{Op: Delete, Entity: "tickets", ID: "a", Before: map[string]interface{}{"_id": "a"}})
+	require.NoError(t, err)
+	_, err = j.Append(Entry{Op: Update, Entity: "users", ID: "1", Undoes: 1})
+	require.NoError(t, err)
+
+	// a crash while writing leaves a partial line
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":5,"op":"ins`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened := openJournal(t, filename)
+	assert.Equal(t, j.Entries(), reopened.Entries())
+
+	pending := reopened.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, 3, pending[0].Seq)
+	assert.Equal(t, 4, pending[1].Seq)
+
+	// the undone change and the change undoing it can't be undone
+	undoable := reopened.Undoable(5)
+	require.Len(t, undoable, 1)
+	assert.Equal(t, 3, undoable[0].Seq)
+
+	last, err := reopened.Append(Entry{Op: Insert, Entity: "users", ID: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, 5, last.Seq)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `{"seq":5,"time":"2021-06-01T10:00:00Z","op":"insert","entity":"users","id":"2"}`+"\n")
+}
+
+func TestReplay(t *testing.T) {
+	s := store.New(nil, model.Users{
+		{"_id": float64(1), "name": "Francis Bailey"},
+		{"_id": float64(2), "name": "Cross Barlow"},
+	}, nil)
+
+	applied, err := Replay(s, []Entry{
+		{Seq: 1, Op: Update, Entity: "users", ID: "1", After: map[string]interface{}{"_id": float64(1), "name": "Francis Rasmussen"}},
+		{Seq: 2, Op: Delete, Entity: "users", ID: "2"},
+		{Seq: 3, Op: Insert, Entity: "users", ID: "3", After: map[string]interface{}{"_id": float64(3), "name": "Rose Newton"}},
+		// replaying a change twice doesn't fail
+		{Seq: 4, Op: Insert, Entity: "users", ID: "3", After: map[string]interface{}{"_id": float64(3), "name": "Rose Newton"}},
+		{Seq: 5, Op: Insert, Entity: "users", ID: "x", After: map[string]interface{}{"_id": "x"}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "change 5: ")
+	assert.Equal(t, 4, applied)
+
+	records, err := s.Records("users")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"_id": float64(1), "name": "Francis Rasmussen"},
+		{"_id": float64(3), "name": "Rose Newton"},
+	}, records)
+}
+
+func TestEntry_Fields(t *testing.T) {
+	e := Entry{
+		Before: map[string]interface{}{"_id": "a", "status": "open", "tags": []interface{}{"Ohio"}, "assignee_id": float64(1)},
+		After:  map[string]interface{}{"_id": "a", "status": "solved", "tags": []interface{}{"Ohio"}, "priority": "high"},
+	}
+	assert.Equal(t, []string{"assignee_id", "priority", "status"}, e.Fields())
+	assert.Equal(t, Update, OpOf(e.Before, e.After))
+	assert.Equal(t, Insert, OpOf(nil, e.After))
+	assert.Equal(t, Delete, OpOf(e.Before, nil))
+}
@@ -1,43 +1,105 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/jaimem88/zearch/internal/reader"
 )
 
-// Data holds all the parsed data per entity.
-type Data struct {
-	Organizations Organizations
-	Users         Users
-	Tickets       Tickets
+// InvalidRecord describes a record that failed validation
+type InvalidRecord struct {
+	Filename string
+	// Index is the position of the record in the file starting at 0
+	Index int
+	Err   error
 }
 
-// LoadData will read all filenames content and return the parsed data into their
-// respective types.
-func LoadData(orgsFilename, usersFilename, ticketsFilename string) (*Data, error) {
-	var orgs Organizations
-	var users Users
-	var tickets Tickets
+// ValidationError lists every invalid record found while loading the data files
+type ValidationError struct {
+	Records []InvalidRecord
+}
+
+// Error lists every invalid field of every invalid record, one per line
+func (e *ValidationError) Error() string {
+	lines := []string{fmt.Sprintf("found %d invalid records", len(e.Records))}
+	for _, rec := range e.Records {
+		var fieldErrs FieldErrors
+		if !errors.As(rec.Err, &fieldErrs) {
+			lines = append(lines, fmt.Sprintf("%s record %d: %s", rec.Filename, rec.Index, rec.Err))
+			continue
+		}
+
+		for _, fieldErr := range fieldErrs {
+			lines = append(lines, fmt.Sprintf("%s record %d: %s", rec.Filename, rec.Index, fieldErr))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Stream is a file read by StreamFiles, add receives every record of the file. Cells
+// are the types of the cells of CSV files.
+type Stream struct {
+	Filename string
+	Cells    reader.CellTypes
+	Add      func(record map[string]interface{}) error
+}
+
+// StreamFiles reads every file in its own goroutine and passes each record to the add
+// function of its stream as soon as it is decoded. progress is optional and is called
+// from every goroutine, see reader.StreamFile.
+// Records whose add returns FieldErrors are skipped and reported at the end in a
+// ValidationError, so every valid record is added even when an error is returned.
+func StreamFiles(streams []Stream, progress reader.ProgressFunc) error {
+	errs := make([]error, len(streams))
+	invalid := make([][]InvalidRecord, len(streams))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(streams))
+
+	for k, stream := range streams {
+		go func(k int, filename string, cells reader.CellTypes, add func(map[string]interface{}) error) {
+			defer wg.Done()
+
+			index := 0
+			err := reader.StreamFile(filename, cells, func(record map[string]interface{}) error {
+				defer func() { index++ }()
+
+				err := add(record)
+
+				var fieldErrs FieldErrors
+				if errors.As(err, &fieldErrs) {
+					invalid[k] = append(invalid[k], InvalidRecord{Filename: filename, Index: index, Err: err})
+					return nil
+				}
+
+				return err
+			}, progress)
+			if err != nil {
+				errs[k] = fmt.Errorf("failed to load: %s %w", filename, err)
+			}
+		}(k, stream.Filename, stream.Cells, stream.Add)
+	}
+
+	wg.Wait()
 
-	err := reader.ReadJSONFile(orgsFilename, &orgs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load: %s %w", orgsFilename, err)
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
 
-	err = reader.ReadJSONFile(usersFilename, &users)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load: %s %w", usersFilename, err)
+	var invalidRecords []InvalidRecord
+	for _, records := range invalid {
+		invalidRecords = append(invalidRecords, records...)
 	}
 
-	err = reader.ReadJSONFile(ticketsFilename, &tickets)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load: %s %w", ticketsFilename, err)
+	if len(invalidRecords) > 0 {
+		return &ValidationError{Records: invalidRecords}
 	}
 
-	return &Data{
-		Organizations: orgs,
-		Users:         users,
-		Tickets:       tickets,
-	}, nil
+	return nil
 }
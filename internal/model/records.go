@@ -0,0 +1,434 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/reader"
+)
+
+// TimeLayout is the format of the timestamps in the Zendesk data e.g. 2016-04-28T11:19:34 -10:00
+const TimeLayout = "2006-01-02T15:04:05 -07:00"
+
+// OrganizationRecord is the typed representation of an Organization. Fields that are
+// not known are kept in Extras.
+type OrganizationRecord struct {
+	ID            OrgID
+	URL           string
+	ExternalID    string
+	Name          string
+	DomainNames   []string
+	CreatedAt     string
+	Details       string
+	SharedTickets bool
+	Tags          []string
+	Extras        map[string]interface{}
+}
+
+// UserRecord is the typed representation of a User. OrganizationID is nil when the
+// user does not belong to an organization. Fields that are not known are kept in Extras.
+type UserRecord struct {
+	ID             UserID
+	URL            string
+	ExternalID     string
+	Name           string
+	Alias          string
+	CreatedAt      string
+	Active         bool
+	Verified       bool
+	Shared         bool
+	Locale         string
+	Timezone       string
+	LastLoginAt    string
+	Email          string
+	Phone          string
+	Signature      string
+	OrganizationID *OrgID
+	Tags           []string
+	Suspended      bool
+	Role           string
+	Extras         map[string]interface{}
+}
+
+// TicketRecord is the typed representation of a Ticket. SubmitterID, AssigneeID and
+// OrganizationID are nil when the ticket does not have them. Fields that are not known
+// are kept in Extras.
+type TicketRecord struct {
+	ID             TicketID
+	URL            string
+	ExternalID     string
+	CreatedAt      string
+	Type           string
+	Subject        string
+	Description    string
+	Priority       string
+	Status         string
+	SubmitterID    *UserID
+	AssigneeID     *UserID
+	OrganizationID *OrgID
+	Tags           []string
+	HasIncidents   bool
+	DueAt          string
+	Via            string
+	Extras         map[string]interface{}
+}
+
+// The types of the fields of organizations, users and tickets that are not strings,
+// used to read CSV files, see reader.CellTypes
+var (
+	OrganizationCells = reader.CellTypes{
+		"_id":            reader.CellNumber,
+		"domain_names":   reader.CellArray,
+		"shared_tickets": reader.CellBoolean,
+		"tags":           reader.CellArray,
+	}
+	UserCells = reader.CellTypes{
+		"_id":             reader.CellNumber,
+		"active":          reader.CellBoolean,
+		"verified":        reader.CellBoolean,
+		"shared":          reader.CellBoolean,
+		"organization_id": reader.CellNumber,
+		"tags":            reader.CellArray,
+		"suspended":       reader.CellBoolean,
+	}
+	TicketCells = reader.CellTypes{
+		"submitter_id":    reader.CellNumber,
+		"assignee_id":     reader.CellNumber,
+		"organization_id": reader.CellNumber,
+		"tags":            reader.CellArray,
+		"has_incidents":   reader.CellBoolean,
+	}
+)
+
+// ID types of the records of the entities defined in the entities config
+const (
+	IDNumber = "number"
+	IDString = "string"
+)
+
+// RecordID validates the ID field of a record of an entity defined in the entities
+// config and returns it as a string, whole numbers are written without decimals.
+// Returns FieldErrors when the ID is missing or is not of idType.
+func RecordID(record map[string]interface{}, field, idType string) (string, error) {
+	r := &fieldReader{record: record}
+
+	var id string
+	switch {
+	case idType == IDString:
+		id = r.requiredString(field)
+	case r.required(field):
+		n, _ := r.optionalID(field)
+		id = strconv.FormatFloat(n, 'f', -1, 64)
+	}
+
+	return id, r.err()
+}
+
+// FieldError describes a field that does not have the expected type
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+// Error returns the field followed by what is wrong with it
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %q: %s", e.Field, e.Msg)
+}
+
+// FieldErrors contains every invalid field of a record
+type FieldErrors []FieldError
+
+// Error joins the errors of every field
+func (e FieldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		msgs = append(msgs, fieldErr.Error())
+	}
+
+	return strings.Join(msgs, ", ")
+}
+
+// Record validates the organization and converts it into an OrganizationRecord.
+// Returns FieldErrors listing every invalid field.
+func (o Organization) Record() (OrganizationRecord, error) {
+	r := &fieldReader{record: o}
+
+	rec := OrganizationRecord{
+		ID:            OrgID(r.id("_id")),
+		URL:           r.string("url"),
+		ExternalID:    r.string("external_id"),
+		Name:          r.string("name"),
+		DomainNames:   r.strings("domain_names"),
+		CreatedAt:     r.timestamp("created_at"),
+		Details:       r.string("details"),
+		SharedTickets: r.bool("shared_tickets"),
+		Tags:          r.strings("tags"),
+	}
+	rec.Extras = r.extras()
+
+	return rec, r.err()
+}
+
+// Record validates the user and converts it into a UserRecord.
+// Returns FieldErrors listing every invalid field.
+func (u User) Record() (UserRecord, error) {
+	r := &fieldReader{record: u}
+
+	rec := UserRecord{
+		ID:          UserID(r.id("_id")),
+		URL:         r.string("url"),
+		ExternalID:  r.string("external_id"),
+		Name:        r.string("name"),
+		Alias:       r.string("alias"),
+		CreatedAt:   r.timestamp("created_at"),
+		Active:      r.bool("active"),
+		Verified:    r.bool("verified"),
+		Shared:      r.bool("shared"),
+		Locale:      r.string("locale"),
+		Timezone:    r.string("timezone"),
+		LastLoginAt: r.timestamp("last_login_at"),
+		Email:       r.string("email"),
+		Phone:       r.string("phone"),
+		Signature:   r.string("signature"),
+		Tags:        r.strings("tags"),
+		Suspended:   r.bool("suspended"),
+		Role:        r.string("role"),
+	}
+
+	if orgID, ok := r.optionalID("organization_id"); ok {
+		id := OrgID(orgID)
+		rec.OrganizationID = &id
+	}
+
+	rec.Extras = r.extras()
+
+	return rec, r.err()
+}
+
+// Record validates the ticket and converts it into a TicketRecord.
+// Returns FieldErrors listing every invalid field.
+func (t Ticket) Record() (TicketRecord, error) {
+	r := &fieldReader{record: t}
+
+	rec := TicketRecord{
+		ID:           TicketID(r.requiredString("_id")),
+		URL:          r.string("url"),
+		ExternalID:   r.string("external_id"),
+		CreatedAt:    r.timestamp("created_at"),
+		Type:         r.string("type"),
+		Subject:      r.string("subject"),
+		Description:  r.string("description"),
+		Priority:     r.string("priority"),
+		Status:       r.string("status"),
+		Tags:         r.strings("tags"),
+		HasIncidents: r.bool("has_incidents"),
+		DueAt:        r.timestamp("due_at"),
+		Via:          r.string("via"),
+	}
+
+	if submitterID, ok := r.optionalID("submitter_id"); ok {
+		id := UserID(submitterID)
+		rec.SubmitterID = &id
+	}
+
+	if assigneeID, ok := r.optionalID("assignee_id"); ok {
+		id := UserID(assigneeID)
+		rec.AssigneeID = &id
+	}
+
+	if orgID, ok := r.optionalID("organization_id"); ok {
+		id := OrgID(orgID)
+		rec.OrganizationID = &id
+	}
+
+	rec.Extras = r.extras()
+
+	return rec, r.err()
+}
+
+// fieldReader reads the fields of a record checking their types. Every field that is
+// read is marked as known and every error is kept so they can all be reported at once.
+// Missing fields and null values are allowed unless stated otherwise.
+type fieldReader struct {
+	record map[string]interface{}
+	known  map[string]bool
+	errs   FieldErrors
+}
+
+func (r *fieldReader) get(field string) (interface{}, bool) {
+	if r.known == nil {
+		r.known = map[string]bool{}
+	}
+
+	r.known[field] = true
+
+	v, ok := r.record[field]
+	if !ok || v == nil {
+		return nil, false
+	}
+
+	return v, true
+}
+
+func (r *fieldReader) fail(field, format string, args ...interface{}) {
+	r.errs = append(r.errs, FieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (r *fieldReader) string(field string) string {
+	v, ok := r.get(field)
+	if !ok {
+		return ""
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		r.fail(field, "expected a string but got %s", describe(v))
+	}
+
+	return s
+}
+
+func (r *fieldReader) requiredString(field string) string {
+	if !r.required(field) {
+		return ""
+	}
+
+	return r.string(field)
+}
+
+func (r *fieldReader) required(field string) bool {
+	if _, ok := r.get(field); !ok {
+		r.fail(field, "is required")
+		return false
+	}
+
+	return true
+}
+
+func (r *fieldReader) bool(field string) bool {
+	v, ok := r.get(field)
+	if !ok {
+		return false
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		r.fail(field, "expected a boolean but got %s", describe(v))
+	}
+
+	return b
+}
+
+func (r *fieldReader) strings(field string) []string {
+	v, ok := r.get(field)
+	if !ok {
+		return nil
+	}
+
+	elems, ok := v.([]interface{})
+	if !ok {
+		r.fail(field, "expected an array of strings but got %s", describe(v))
+		return nil
+	}
+
+	values := make([]string, 0, len(elems))
+	for k, elem := range elems {
+		s, ok := elem.(string)
+		if !ok {
+			r.fail(field, "expected element %d to be a string but got %s", k, describe(elem))
+			continue
+		}
+
+		values = append(values, s)
+	}
+
+	return values
+}
+
+func (r *fieldReader) timestamp(field string) string {
+	s := r.string(field)
+	if s == "" {
+		return s
+	}
+
+	if _, err := time.Parse(TimeLayout, s); err != nil {
+		r.fail(field, "expected a timestamp like %q but got %q", TimeLayout, s)
+	}
+
+	return s
+}
+
+// id reads a required whole number
+func (r *fieldReader) id(field string) float64 {
+	if !r.required(field) {
+		return 0
+	}
+
+	id, _ := r.optionalID(field)
+
+	return id
+}
+
+// optionalID reads a whole number, the second value is false when the field is missing
+// or invalid
+func (r *fieldReader) optionalID(field string) (float64, bool) {
+	v, ok := r.get(field)
+	if !ok {
+		return 0, false
+	}
+
+	id, ok := v.(float64)
+	if !ok || id != math.Trunc(id) {
+		r.fail(field, "expected a whole number but got %s", describe(v))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// extras returns the fields of the record that were not read
+func (r *fieldReader) extras() map[string]interface{} {
+	var extras map[string]interface{}
+	for k, v := range r.record {
+		if r.known[k] {
+			continue
+		}
+
+		if extras == nil {
+			extras = map[string]interface{}{}
+		}
+
+		extras[k] = v
+	}
+
+	return extras
+}
+
+func (r *fieldReader) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+
+	return r.errs
+}
+
+// describe returns the JSON type and value of v for error messages
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganization_Record(t *testing.T) {
+	rec, err := Organization{
+		"_id":            float64(101),
+		"name":           "Enthaze",
+		"domain_names":   []interface{}{"kage.com"},
+		"created_at":     "2016-05-21T11:10:28 -10:00",
+		"shared_tickets": false,
+		"details":        nil,
+		"region":         "APAC",
+	}.Record()
+	require.NoError(t, err)
+
+	assert.Equal(t, OrganizationRecord{
+		ID:          101,
+		Name:        "Enthaze",
+		DomainNames: []string{"kage.com"},
+		CreatedAt:   "2016-05-21T11:10:28 -10:00",
+		Extras:      map[string]interface{}{"region": "APAC"},
+	}, rec)
+}
+
+func TestUser_Record(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          User
+		expectedOrgID *OrgID
+		expectedError string
+	}{
+		{
+			name:          "valid",
+			user:          User{"_id": float64(1), "organization_id": float64(119), "active": true},
+			expectedOrgID: orgID(119),
+		},
+		{
+			name: "without_organization",
+			user: User{"_id": float64(1), "organization_id": nil},
+		},
+		{
+			name:          "missing_id",
+			user:          User{"name": "Francis Bailey"},
+			expectedError: `field "_id": is required`,
+		},
+		{
+			name: "every_invalid_field_is_reported",
+			user: User{
+				"_id":             "1",
+				"active":          "yes",
+				"organization_id": 1.5,
+				"tags":            []interface{}{"Leola", float64(2)},
+				"last_login_at":   "yesterday",
+			},
+			expectedError: `field "_id": expected a whole number but got string "1", ` +
+				`field "active": expected a boolean but got string "yes", ` +
+				`field "last_login_at": expected a timestamp like "2006-01-02T15:04:05 -07:00" but got "yesterday", ` +
+				`field "tags": expected element 1 to be a string but got number 2, ` +
+				`field "organization_id": expected a whole number but got number 1.5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := tt.user.Record()
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOrgID, rec.OrganizationID)
+		})
+	}
+}
+
+func TestTicket_Record(t *testing.T) {
+	rec, err := Ticket{
+		"_id":          "436bf9b0",
+		"submitter_id": float64(38),
+		"assignee_id":  float64(24),
+		"due_at":       "2016-07-31T02:37:50 -10:00",
+	}.Record()
+	require.NoError(t, err)
+
+	require.NotNil(t, rec.SubmitterID)
+	require.NotNil(t, rec.AssigneeID)
+	assert.Equal(t, TicketID("436bf9b0"), rec.ID)
+	assert.Equal(t, UserID(38), *rec.SubmitterID)
+	assert.Equal(t, UserID(24), *rec.AssigneeID)
+	assert.Nil(t, rec.OrganizationID)
+	assert.Nil(t, rec.Extras)
+
+	_, err = Ticket{"_id": float64(1)}.Record()
+	require.EqualError(t, err, `field "_id": expected a string but got number 1`)
+}
+
+func TestRecordID(t *testing.T) {
+	tests := []struct {
+		name          string
+		record        map[string]interface{}
+		field         string
+		idType        string
+		expectedID    string
+		expectedError string
+	}{
+		{
+			name:       "number",
+			record:     map[string]interface{}{"id": float64(360000010)},
+			field:      "id",
+			idType:     IDNumber,
+			expectedID: "360000010",
+		},
+		{
+			name:       "string",
+			record:     map[string]interface{}{"key": "gold"},
+			field:      "key",
+			idType:     IDString,
+			expectedID: "gold",
+		},
+		{
+			name:          "missing",
+			record:        map[string]interface{}{"_id": float64(1)},
+			field:         "id",
+			idType:        IDNumber,
+			expectedError: `field "id": is required`,
+		},
+		{
+			name:          "wrong_type",
+			record:        map[string]interface{}{"id": "1"},
+			field:         "id",
+			idType:        IDNumber,
+			expectedError: `field "id": expected a whole number but got string "1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := RecordID(tt.record, tt.field, tt.idType)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, id)
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Records: []InvalidRecord{
+		{
+			Filename: "users.json",
+			Index:    3,
+			Err: FieldErrors{
+				{Field: "_id", Msg: "is required"},
+				{Field: "active", Msg: `expected a boolean but got string "yes"`},
+			},
+		},
+		{Filename: "tickets.json", Index: 0, Err: FieldErrors{{Field: "_id", Msg: "is required"}}},
+	}}
+
+	assert.Equal(t, `found 2 invalid records
+users.json record 3: field "_id": is required
+users.json record 3: field "active": expected a boolean but got string "yes"
+tickets.json record 0: field "_id": is required`, err.Error())
+}
+
+func orgID(id OrgID) *OrgID {
+	return &id
+}
@@ -16,6 +16,7 @@ shared_tickets      {{ index .Organization "shared_tickets" }}
 tags                {{ index .Organization "tags" }}
 {{ range $index, $element := .UserNames }}user_{{ $index }}              {{ $element }}
 {{ end }}{{ range $index, $element := .TicketSubjects }}ticket_{{ $index }}            {{ $element }}
+{{ end }}{{ if .Score }}_score              {{ printf "%.3f" .Score }}
 {{ end }}`
 
 var UserResultTemplate = template.Must(template.New("userResultTemplate").Parse(userResultTemplate))
@@ -41,7 +42,9 @@ tags                {{ index .User "tags" }}
 suspended           {{ index .User "suspended" }}
 role                {{ index .User "role" }}
 organization_name   {{ .OrganizationName }}
-{{ range $index, $element := .TicketSubjects }}ticket_{{ $index }}            {{ $element }}
+{{ range $index, $element := .SubmittedTickets }}submitted_ticket_{{ $index }}  {{ $element }}
+{{ end }}{{ range $index, $element := .AssignedTickets }}assigned_ticket_{{ $index }}   {{ $element }}
+{{ end }}{{ if .Score }}_score              {{ printf "%.3f" .Score }}
 {{ end }}`
 
 var TicketResultTemplate = template.Must(template.New("ticketResultTemplate").Parse(ticketResultTemplate))
@@ -63,5 +66,8 @@ tags                {{ index .Ticket "tags" }}
 has_incidents       {{ index .Ticket "has_incidents" }}
 due_at              {{ index .Ticket "due_at" }}
 via                 {{ index .Ticket "via" }}
-organization_name	{{ .OrganizationName }}
-`
+organization_name   {{ .OrganizationName }}
+submitter_name      {{ .SubmitterName }}
+assignee_name       {{ .AssigneeName }}
+{{ if .Score }}_score              {{ printf "%.3f" .Score }}
+{{ end }}`
@@ -1,5 +1,11 @@
 package model
 
+import "math"
+
+// ScoreField is the name of the related field holding the relevance score of a
+// result found by a text query
+const ScoreField = "_score"
+
 // These types serve as aliases to help read the code
 type (
 	UserID   float64
@@ -7,22 +13,120 @@ type (
 	TicketID string
 )
 
+// Result is implemented by the search result of every entity so they can be rendered
+// in different formats.
+type Result interface {
+	// Fields returns the fields of the record found
+	Fields() map[string]interface{}
+	// Related returns the fields of related entities in the order they are displayed
+	Related() []Field
+}
+
+// Field is a named value
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
 // OrganizationResult contains the result of a search
 type OrganizationResult struct {
 	Organization
 	UserNames      []string
 	TicketSubjects []string
+	// Score is the relevance of the result for text queries, zero otherwise
+	Score float64
+}
+
+// Fields returns the fields of the organization
+func (r OrganizationResult) Fields() map[string]interface{} {
+	return r.Organization
+}
+
+// Related returns the names of its users and the subjects of its tickets
+func (r OrganizationResult) Related() []Field {
+	return withScore([]Field{
+		{Name: "user_names", Value: r.UserNames},
+		{Name: "ticket_subjects", Value: r.TicketSubjects},
+	}, r.Score)
 }
 
 type TicketResult struct {
 	Ticket
 	OrganizationName string
+	SubmitterName    string
+	AssigneeName     string
+	// Score is the relevance of the result for text queries, zero otherwise
+	Score float64
+}
+
+// Fields returns the fields of the ticket
+func (r TicketResult) Fields() map[string]interface{} {
+	return r.Ticket
+}
+
+// Related returns the names of its organization, submitter and assignee
+func (r TicketResult) Related() []Field {
+	return withScore([]Field{
+		{Name: "organization_name", Value: r.OrganizationName},
+		{Name: "submitter_name", Value: r.SubmitterName},
+		{Name: "assignee_name", Value: r.AssigneeName},
+	}, r.Score)
 }
 
 type UserResult struct {
 	User
 	OrganizationName string
-	TicketSubjects   []string
+	// SubmittedTickets and AssignedTickets contain the subjects of the tickets
+	// where the user is the submitter or the assignee
+	SubmittedTickets []string
+	AssignedTickets  []string
+	// Score is the relevance of the result for text queries, zero otherwise
+	Score float64
+}
+
+// Fields returns the fields of the user
+func (r UserResult) Fields() map[string]interface{} {
+	return r.User
+}
+
+// Related returns the name of its organization and the subjects of its tickets
+func (r UserResult) Related() []Field {
+	return withScore([]Field{
+		{Name: "organization_name", Value: r.OrganizationName},
+		{Name: "submitted_tickets", Value: r.SubmittedTickets},
+		{Name: "assigned_tickets", Value: r.AssignedTickets},
+	}, r.Score)
+}
+
+// EntityResult contains the result of a search of an entity defined in the entities
+// config. Relations holds the fields shown from related records, see store.RelationConfig.
+type EntityResult struct {
+	Record map[string]interface{}
+	// Display are the fields printed in tables in order, all of them when it is empty
+	Display   []string
+	Relations []Field
+	// Score is the relevance of the result for text queries, zero otherwise
+	Score float64
+}
+
+// Fields returns the fields of the record
+func (r EntityResult) Fields() map[string]interface{} {
+	return r.Record
+}
+
+// Related returns the fields shown from the related records
+func (r EntityResult) Related() []Field {
+	return withScore(r.Relations, r.Score)
+}
+
+// withScore adds the score to the related fields when the result was ranked,
+// rounded so it is easier to read
+func withScore(fields []Field, score float64) []Field {
+	if score == 0 {
+		return fields
+	}
+
+	return append(fields, Field{Name: ScoreField, Value: math.Round(score*1000) / 1000})
 }
 
 type Organization map[string]interface{}
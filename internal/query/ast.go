@@ -0,0 +1,175 @@
+// Package query implements the boolean query language used to search entities,
+// for example:
+//
+//	status:open AND (priority:high OR priority:urgent) AND NOT tags:Ohio
+//
+// Parse turns a query into an Expr tree that the store evaluates against its indexes.
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextField is the field name used to write Text queries
+const TextField = "text"
+
+// Expr is a node of a parsed query
+type Expr interface {
+	String() string
+}
+
+// Term matches the records where Field has Value, compared using Mode.
+// Array fields match when any of their elements does.
+type Term struct {
+	Field string
+	Value string
+	// Mode is Exact when empty
+	Mode Mode
+}
+
+// Text matches the records whose free-text fields contain every word of Value,
+// e.g. text:"printer broken"
+type Text struct {
+	Value string
+}
+
+// Range matches the records where Field is within the bounds, e.g. _id:10..20 or
+// due_at:<"2016-08-01T00:00:00 -10:00". A nil bound is unbounded.
+type Range struct {
+	Field string
+	Min   *Bound
+	Max   *Bound
+}
+
+// Bound is the value at one end of a Range, see ParseOrdered for the values accepted
+type Bound struct {
+	Value     string
+	Inclusive bool
+}
+
+// Predicates of Is
+const (
+	IsNull    = "null"
+	IsMissing = "missing"
+	IsEmpty   = "empty"
+)
+
+// Is matches the records where Field is null, missing or empty, e.g. assignee_id:is:missing.
+// A field is null when its value is JSON null, missing when the record doesn't have it and
+// empty when its value is an empty string or an empty array.
+type Is struct {
+	Field     string
+	Predicate string
+}
+
+// Join matches the records related to the records of another entity matched by Expr,
+// e.g. organization.tags:West searching tickets. Relation names the related entity,
+// see the store for the relations of every entity. To-many relations match when any
+// of the related records does.
+type Join struct {
+	Relation string
+	Expr     Expr
+}
+
+// And matches the records matched by both Left and Right
+type And struct {
+	Left  Expr
+	Right Expr
+}
+
+// Or matches the records matched by either Left or Right
+type Or struct {
+	Left  Expr
+	Right Expr
+}
+
+// Not matches the records that are not matched by Expr
+type Not struct {
+	Expr Expr
+}
+
+// String returns the term as it is written in queries
+func (t Term) String() string {
+	if t.Mode != "" && t.Mode != Exact {
+		return fmt.Sprintf("%s:%s:%s", t.Field, t.Mode, quote(t.Value))
+	}
+
+	return fmt.Sprintf("%s:%s", t.Field, quote(t.Value))
+}
+
+// String returns the text query as it is written in queries
+func (t Text) String() string {
+	return fmt.Sprintf("%s:%s", TextField, quote(t.Value))
+}
+
+// String returns the range as it is written in queries, bounded ranges that are not
+// inclusive are written as two comparisons
+func (r Range) String() string {
+	switch {
+	case r.Min != nil && r.Max != nil && r.Min.Inclusive && r.Max.Inclusive:
+		return fmt.Sprintf("%s:%s..%s", r.Field, quote(r.Min.Value), quote(r.Max.Value))
+	case r.Min != nil && r.Max != nil:
+		return fmt.Sprintf("(%s AND %s)", Range{Field: r.Field, Min: r.Min}, Range{Field: r.Field, Max: r.Max})
+	case r.Min != nil && r.Min.Inclusive:
+		return fmt.Sprintf("%s:>=%s", r.Field, quote(r.Min.Value))
+	case r.Min != nil:
+		return fmt.Sprintf("%s:>%s", r.Field, quote(r.Min.Value))
+	case r.Max != nil && r.Max.Inclusive:
+		return fmt.Sprintf("%s:<=%s", r.Field, quote(r.Max.Value))
+	case r.Max != nil:
+		return fmt.Sprintf("%s:<%s", r.Field, quote(r.Max.Value))
+	default:
+		return fmt.Sprintf("%s:..", r.Field)
+	}
+}
+
+// String returns the predicate as it is written in queries
+func (i Is) String() string {
+	return fmt.Sprintf("%s:is:%s", i.Field, i.Predicate)
+}
+
+// String returns the join as it is written in queries, with the relation before the field
+func (j Join) String() string {
+	switch e := j.Expr.(type) {
+	case Term:
+		e.Field = j.Relation + "." + e.Field
+		return e.String()
+	case Range:
+		e.Field = j.Relation + "." + e.Field
+		return e.String()
+	case Is:
+		e.Field = j.Relation + "." + e.Field
+		return e.String()
+	case Join:
+		e.Relation = j.Relation + "." + e.Relation
+		return e.String()
+	default:
+		return j.Relation + "." + j.Expr.String()
+	}
+}
+
+// String returns the expression as it is written in queries, in parentheses
+func (a And) String() string {
+	return fmt.Sprintf("(%s AND %s)", a.Left, a.Right)
+}
+
+// String returns the expression as it is written in queries, in parentheses
+func (o Or) String() string {
+	return fmt.Sprintf("(%s OR %s)", o.Left, o.Right)
+}
+
+// String returns the expression as it is written in queries
+func (n Not) String() string {
+	return fmt.Sprintf("NOT %s", n.Expr)
+}
+
+// quote wraps value in double quotes when it can't be written as a bare word
+func quote(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"()\\") || strings.Contains(value, "..") ||
+		strings.HasPrefix(value, "<") || strings.HasPrefix(value, ">") || lexModifier(value) != "" {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+
+	return value
+}
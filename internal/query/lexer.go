@@ -0,0 +1,237 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokWord
+)
+
+// token is a lexical unit of a query. Words are split into field and value
+// at their first unquoted colon, e.g. `name:"Francis Bailey"`. Values can start with
+// an unquoted comparison operator, e.g. `_id:>=10`, be a range, e.g. `_id:10..20`,
+// or a predicate, e.g. `assignee_id:is:missing`.
+type token struct {
+	kind tokenKind
+	// raw is the text as written in the query, used for error messages
+	raw string
+	pos int
+
+	hasColon bool
+	field    string
+	value    string
+
+	// op is the comparison operator written before the value
+	op string
+	// isRange is true when the value has an unquoted .. and lower is the value before it
+	isRange bool
+	lower   string
+	// modifier is the unquoted is: predicate or match mode written before the value,
+	// e.g. is for assignee_id:is:missing or prefix for name:prefix:Fran
+	modifier string
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+
+	return t.raw
+}
+
+var keywords = map[string]tokenKind{
+	"AND": tokAnd,
+	"OR":  tokOr,
+	"NOT": tokNot,
+}
+
+// lex splits input into tokens. Keywords are case insensitive and only recognised
+// when written as a bare word, so `status:and` or `"OR"` are not operators.
+func lex(input string) ([]token, error) {
+	var tokens []token
+
+	pos := 0
+	for {
+		for pos < len(input) {
+			r, size := utf8.DecodeRuneInString(input[pos:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			pos += size
+		}
+
+		if pos >= len(input) {
+			tokens = append(tokens, token{kind: tokEOF, pos: pos})
+			return tokens, nil
+		}
+
+		switch input[pos] {
+		case '(':
+			tokens = append(tokens, token{kind: tokLParen, raw: "(", pos: pos})
+			pos++
+		case ')':
+			tokens = append(tokens, token{kind: tokRParen, raw: ")", pos: pos})
+			pos++
+		default:
+			tok, err := lexWord(input, pos)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, tok)
+			pos += len(tok.raw)
+		}
+	}
+}
+
+func lexWord(input string, start int) (token, error) {
+	tok := token{kind: tokWord, pos: start}
+	quoted := false
+	// position right after the first colon where the value starts
+	valueStart := -1
+
+	var b strings.Builder
+	pos := start
+	for pos < len(input) {
+		r, size := utf8.DecodeRuneInString(input[pos:])
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+
+		switch {
+		case r == '"':
+			end, value, err := lexQuoted(input, pos)
+			if err != nil {
+				return token{}, err
+			}
+
+			b.WriteString(value)
+			quoted = true
+			pos = end
+			continue
+		case r == ':' && !tok.hasColon:
+			tok.hasColon = true
+			tok.field = b.String()
+			b.Reset()
+			valueStart = pos + size
+		default:
+			if n := tok.lexOperator(input, pos, valueStart, &b); n > 0 {
+				size = n
+			} else {
+				b.WriteRune(r)
+			}
+		}
+
+		pos += size
+	}
+
+	tok.raw = input[start:pos]
+	tok.value = b.String()
+	if kind, ok := keywords[strings.ToUpper(tok.raw)]; ok && !quoted && !tok.hasColon {
+		tok.kind = kind
+	}
+
+	return tok, nil
+}
+
+// lexOperator reads the modifier, comparison operator or range dots of a value at pos
+// into tok and returns their length, 0 when there is none. Modifiers and operators are
+// only allowed right after the colon e.g. due_at:<... and prefix:Fran.
+func (tok *token) lexOperator(input string, pos, valueStart int, b *strings.Builder) int {
+	rest := input[pos:]
+	switch {
+	case pos == valueStart && tok.modifier == "" && lexModifier(rest) != "":
+		tok.modifier = lexModifier(rest)
+		return len(tok.modifier) + 1
+	case (rest[0] == '<' || rest[0] == '>') && pos == valueStart:
+		tok.op = rest[:1]
+		if strings.HasPrefix(rest[1:], "=") {
+			tok.op += "="
+		}
+
+		return len(tok.op)
+	case strings.HasPrefix(rest, "..") && tok.hasColon && tok.modifier == "" && !tok.isRange:
+		tok.isRange = true
+		tok.lower = b.String()
+		b.Reset()
+		return len("..")
+	default:
+		return 0
+	}
+}
+
+// isModifier is the name of the is: predicate, match modes are also modifiers
+const isModifier = "is"
+
+// lexModifier returns the lowercase modifier at the start of s, e.g. prefix in
+// prefix:Fran, or an empty string when s doesn't start with a modifier
+func lexModifier(s string) string {
+	i := strings.IndexByte(s, ':')
+	if i <= 0 {
+		return ""
+	}
+
+	name := strings.ToLower(s[:i])
+	if name == isModifier {
+		return name
+	}
+
+	for _, mode := range Modes {
+		if name == string(mode) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// lexQuoted reads a double quoted string starting at start. Backslash escapes a
+// double quote or another backslash, any other backslash is kept so regular
+// expressions like "\d+" can be written as is. It returns the position after the
+// closing quote.
+func lexQuoted(input string, start int) (int, string, error) {
+	var b strings.Builder
+
+	pos := start + 1
+	for pos < len(input) {
+		r, size := utf8.DecodeRuneInString(input[pos:])
+		switch r {
+		case '\\':
+			pos += size
+			if pos >= len(input) {
+				continue
+			}
+
+			r, size = utf8.DecodeRuneInString(input[pos:])
+			if r != '"' && r != '\\' {
+				b.WriteRune('\\')
+			}
+
+			b.WriteRune(r)
+		case '"':
+			return pos + size, b.String(), nil
+		default:
+			b.WriteRune(r)
+		}
+
+		pos += size
+	}
+
+	return 0, "", &SyntaxError{
+		Query: input,
+		Pos:   start,
+		Token: input[start:],
+		Msg:   "unterminated quoted string",
+	}
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jaimem88/zearch/internal/text"
+)
+
+// Mode is how the value of a Term is compared with the values of a field
+type Mode string
+
+// Match modes, written before the value e.g. name:prefix:Fran
+const (
+	// Exact matches values that are equal, including capitalization
+	Exact Mode = "exact"
+	// NoCase matches values that are equal ignoring capitalization
+	NoCase Mode = "nocase"
+	// Prefix matches values that start with the value
+	Prefix Mode = "prefix"
+	// Glob matches values against a pattern where * matches any text and ? any character
+	Glob Mode = "glob"
+	// Regex matches values containing a match of an RE2 regular expression
+	Regex Mode = "regex"
+	// Fuzzy matches values, or words of values, within a few typos of the value
+	// ignoring capitalization, see text.Distance
+	Fuzzy Mode = "fuzzy"
+)
+
+// Modes lists every match mode
+var Modes = []Mode{Exact, NoCase, Prefix, Glob, Regex, Fuzzy}
+
+// ParseMode returns the match mode named s, ignoring capitalization. An empty string
+// is the Exact mode.
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return Exact, nil
+	}
+
+	for _, mode := range Modes {
+		if strings.EqualFold(s, string(mode)) {
+			return mode, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown match mode %q, must be one of %s", s, formatModes())
+}
+
+func formatModes() string {
+	names := make([]string, 0, len(Modes))
+	for _, mode := range Modes {
+		names = append(names, string(mode))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// Matcher returns a function that reports whether a value of the field matches the
+// term. Returns an error when the value is not a valid regular expression.
+func (t Term) Matcher() (func(string) bool, error) {
+	switch t.Mode {
+	case "", Exact:
+		return func(v string) bool { return v == t.Value }, nil
+	case NoCase:
+		return func(v string) bool { return strings.EqualFold(v, t.Value) }, nil
+	case Prefix:
+		return func(v string) bool { return strings.HasPrefix(v, t.Value) }, nil
+	case Glob:
+		re := regexp.MustCompile(globToRegex(t.Value))
+		return re.MatchString, nil
+	case Regex:
+		re, err := regexp.Compile(t.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+
+		return re.MatchString, nil
+	case Fuzzy:
+		value := strings.ToLower(t.Value)
+		max := text.MaxDistance(value)
+		return func(v string) bool {
+			for _, key := range text.FuzzyKeys(v) {
+				if text.Distance(value, key, max) <= max {
+					return true
+				}
+			}
+
+			return false
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown match mode %q", t.Mode)
+	}
+}
+
+// globToRegex converts a glob pattern into an anchored regular expression
+func globToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString(`^(?s)`)
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
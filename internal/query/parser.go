@@ -0,0 +1,333 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError describes why a query could not be parsed and where
+type SyntaxError struct {
+	Query string
+	// Pos is the byte offset of the offending token in Query
+	Pos   int
+	Token string
+	Msg   string
+}
+
+// Error returns the message with the position of the token counted in characters from 1
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d near %q: %s", utf8.RuneCountInString(e.Query[:e.Pos])+1, e.Token, e.Msg)
+}
+
+// Pointer returns the query with a caret under the offending token, e.g.
+//
+//	status:open AND AND priority:high
+//	                ^
+func (e *SyntaxError) Pointer() string {
+	return fmt.Sprintf("%s\n%s^", e.Query, strings.Repeat(" ", utf8.RuneCountInString(e.Query[:e.Pos])))
+}
+
+// Parse parses input into an Expr. Terms are written as field:value, values with
+// spaces or parentheses must be double quoted. Operators are AND, OR and NOT
+// in that order of precedence from lowest to highest, parentheses can be used for
+// grouping and terms written next to each other are joined with AND.
+// The text field is reserved for full-text searches, see Text.
+func Parse(input string) (Expr, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{input: input, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty query")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		if tok.kind == tokRParen {
+			return nil, p.errorf(tok, "unexpected closing parenthesis")
+		}
+
+		return nil, p.errorf(tok, "expected AND, OR or end of query")
+	}
+
+	return expr, nil
+}
+
+type parser struct {
+	input  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &SyntaxError{
+		Query: p.input,
+		Pos:   tok.pos,
+		Token: tok.String(),
+		Msg:   fmt.Sprintf(format, args...),
+	}
+}
+
+// parseOr parses: and (OR and)*
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses: unary ([AND] unary)*
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokNot, tokLParen:
+			// implicit AND
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = And{Left: left, Right: right}
+	}
+}
+
+// parseUnary parses: NOT unary | primary
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind != tokNot {
+		return p.parsePrimary()
+	}
+
+	p.next()
+
+	expr, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return Not{Expr: expr}, nil
+}
+
+// parsePrimary parses: ( or ) | field:value
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected closing parenthesis")
+		}
+
+		return expr, nil
+	case tokWord:
+		return p.parseTerm(tok)
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of query, expected field:value")
+	default:
+		return nil, p.errorf(tok, "unexpected %s, expected field:value", tok.raw)
+	}
+}
+
+func (p *parser) parseTerm(tok token) (Expr, error) {
+	if !tok.hasColon {
+		return nil, p.errorf(tok, "expected field:value, values containing spaces must be quoted")
+	}
+
+	if tok.field == "" {
+		return nil, p.errorf(tok, "missing field name before colon")
+	}
+
+	if i := strings.Index(tok.field, "."); i >= 0 {
+		return p.parseJoin(tok, i)
+	}
+
+	if tok.field == TextField {
+		if tok.op != "" || tok.isRange || tok.modifier != "" {
+			return nil, p.errorf(tok, "text queries cannot have operators, predicates or match modes")
+		}
+
+		if strings.TrimSpace(tok.value) == "" {
+			return nil, p.errorf(tok, "missing words to search for after text:")
+		}
+
+		return Text{Value: tok.value}, nil
+	}
+
+	switch tok.modifier {
+	case "":
+	case isModifier:
+		return p.parseIs(tok)
+	default:
+		term := Term{Field: tok.field, Value: tok.value, Mode: Mode(tok.modifier)}
+		if _, err := term.Matcher(); err != nil {
+			return nil, p.errorf(tok, "%s", err)
+		}
+
+		return term, nil
+	}
+
+	if tok.op != "" || tok.isRange {
+		return p.parseRange(tok)
+	}
+
+	return Term{Field: tok.field, Value: tok.value}, nil
+}
+
+// parseJoin parses relation.field:value where the relation ends at dot, the term
+// after it can be another join e.g. assignee.organization.name:Enthaze
+func (p *parser) parseJoin(tok token, dot int) (Expr, error) {
+	relation := tok.field[:dot]
+	if relation == "" {
+		return nil, p.errorf(tok, "missing relation name before %s", tok.field)
+	}
+
+	if dot == len(tok.field)-1 {
+		return nil, p.errorf(tok, "missing field name after %s", tok.field)
+	}
+
+	related := tok
+	related.field = tok.field[dot+1:]
+	expr, err := p.parseTerm(related)
+	if err != nil {
+		return nil, err
+	}
+
+	return Join{Relation: relation, Expr: expr}, nil
+}
+
+// parseIs parses field:is:null, field:is:missing and field:is:empty
+func (p *parser) parseIs(tok token) (Expr, error) {
+	switch predicate := strings.ToLower(tok.value); predicate {
+	case IsNull, IsMissing, IsEmpty:
+		return Is{Field: tok.field, Predicate: predicate}, nil
+	default:
+		return nil, p.errorf(tok, "unknown predicate is:%s, expected is:%s, is:%s or is:%s", tok.value, IsNull, IsMissing, IsEmpty)
+	}
+}
+
+// parseRange parses field:>value, field:>=value, field:<value, field:<=value and
+// field:min..max where either min or max can be omitted
+func (p *parser) parseRange(tok token) (Expr, error) {
+	var r Range
+	switch {
+	case tok.op != "" && tok.isRange:
+		return nil, p.errorf(tok, "a range cannot have a comparison operator")
+	case tok.op != "":
+		r = comparison(tok)
+	default:
+		r = Range{Field: tok.field}
+		if tok.lower != "" {
+			r.Min = &Bound{Value: tok.lower, Inclusive: true}
+		}
+
+		if tok.value != "" {
+			r.Max = &Bound{Value: tok.value, Inclusive: true}
+		}
+	}
+
+	if r.Min == nil && r.Max == nil {
+		return nil, p.errorf(tok, "missing value to compare %s with", tok.field)
+	}
+
+	if err := p.checkBounds(tok, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// comparison returns the range of field:>value, field:>=value, field:<value and
+// field:<=value, without bounds when the value is missing
+func comparison(tok token) Range {
+	r := Range{Field: tok.field}
+	if tok.value == "" {
+		return r
+	}
+
+	bound := &Bound{Value: tok.value, Inclusive: strings.HasSuffix(tok.op, "=")}
+	if strings.HasPrefix(tok.op, ">") {
+		r.Min = bound
+	} else {
+		r.Max = bound
+	}
+
+	return r
+}
+
+// checkBounds returns an error when the bounds of the range are not numbers or
+// timestamps of the same kind, or the start of the range is after its end
+func (p *parser) checkBounds(tok token, r Range) error {
+	var kind Kind
+	var bounds []float64
+	for _, bound := range []*Bound{r.Min, r.Max} {
+		if bound == nil {
+			continue
+		}
+
+		n, k, ok := ParseOrdered(bound.Value)
+		if !ok {
+			return p.errorf(tok, "%q is not a number or a timestamp like %q", bound.Value, timeLayouts[0])
+		}
+
+		if kind != 0 && k != kind {
+			return p.errorf(tok, "cannot compare a %s with a %s", kind, k)
+		}
+
+		kind = k
+		bounds = append(bounds, n)
+	}
+
+	if len(bounds) == 2 && bounds[0] > bounds[1] {
+		return p.errorf(tok, "the start of the range is after its end")
+	}
+
+	return nil
+}
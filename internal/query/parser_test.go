@@ -0,0 +1,348 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      Expr
+		expectedError string
+	}{
+		{
+			name:     "term",
+			input:    "status:open",
+			expected: Term{Field: "status", Value: "open"},
+		},
+		{
+			name:     "quoted_value",
+			input:    `name:"Francis Bailey"`,
+			expected: Term{Field: "name", Value: "Francis Bailey"},
+		},
+		{
+			name:     "escaped_quote",
+			input:    `subject:"say \"hi\""`,
+			expected: Term{Field: "subject", Value: `say "hi"`},
+		},
+		{
+			name:     "value_with_colons",
+			input:    "url:http://initech.zendesk.com",
+			expected: Term{Field: "url", Value: "http://initech.zendesk.com"},
+		},
+		{
+			name:     "empty_value",
+			input:    `alias:""`,
+			expected: Term{Field: "alias", Value: ""},
+		},
+		{
+			name:     "text",
+			input:    `text:"printer broken" status:open`,
+			expected: And{Left: Text{Value: "printer broken"}, Right: Term{Field: "status", Value: "open"}},
+		},
+		{
+			name:          "empty_text",
+			input:         `status:open text:" "`,
+			expectedError: `syntax error at position 13 near "text:\" \"": missing words to search for after text:`,
+		},
+		{
+			name:     "keywords_as_values",
+			input:    `status:and OR status:"NOT"`,
+			expected: Or{Left: Term{Field: "status", Value: "and"}, Right: Term{Field: "status", Value: "NOT"}},
+		},
+		{
+			name:  "precedence",
+			input: "status:open AND (priority:high OR priority:urgent) AND NOT tags:Ohio",
+			expected: And{
+				Left: And{
+					Left: Term{Field: "status", Value: "open"},
+					Right: Or{
+						Left:  Term{Field: "priority", Value: "high"},
+						Right: Term{Field: "priority", Value: "urgent"},
+					},
+				},
+				Right: Not{Expr: Term{Field: "tags", Value: "Ohio"}},
+			},
+		},
+		{
+			name:  "and_binds_tighter_than_or",
+			input: "a:1 or b:2 and c:3",
+			expected: Or{
+				Left:  Term{Field: "a", Value: "1"},
+				Right: And{Left: Term{Field: "b", Value: "2"}, Right: Term{Field: "c", Value: "3"}},
+			},
+		},
+		{
+			name:     "implicit_and",
+			input:    "a:1 not b:2",
+			expected: And{Left: Term{Field: "a", Value: "1"}, Right: Not{Expr: Term{Field: "b", Value: "2"}}},
+		},
+		{
+			name:     "range",
+			input:    "_id:10..20",
+			expected: Range{Field: "_id", Min: &Bound{Value: "10", Inclusive: true}, Max: &Bound{Value: "20", Inclusive: true}},
+		},
+		{
+			name:     "open_range",
+			input:    "_id:10..",
+			expected: Range{Field: "_id", Min: &Bound{Value: "10", Inclusive: true}},
+		},
+		{
+			name:     "quoted_timestamp_range",
+			input:    `due_at:"2016-07-31T02:37:50 -10:00".."2016-08-15T05:37:32 -10:00"`,
+			expected: Range{Field: "due_at", Min: &Bound{Value: "2016-07-31T02:37:50 -10:00", Inclusive: true}, Max: &Bound{Value: "2016-08-15T05:37:32 -10:00", Inclusive: true}},
+		},
+		{
+			name:  "comparisons",
+			input: `_id:>=10 AND due_at:<"2016-08-01T00:00:00 -10:00" _id:>1.5 created_at:<=2016-05-01`,
+			expected: And{
+				Left: And{
+					Left: And{
+						Left:  Range{Field: "_id", Min: &Bound{Value: "10", Inclusive: true}},
+						Right: Range{Field: "due_at", Max: &Bound{Value: "2016-08-01T00:00:00 -10:00"}},
+					},
+					Right: Range{Field: "_id", Min: &Bound{Value: "1.5"}},
+				},
+				Right: Range{Field: "created_at", Max: &Bound{Value: "2016-05-01", Inclusive: true}},
+			},
+		},
+		{
+			name:     "quoted_operators_are_values",
+			input:    `subject:"<a..b>" alias:">"`,
+			expected: And{Left: Term{Field: "subject", Value: "<a..b>"}, Right: Term{Field: "alias", Value: ">"}},
+		},
+		{
+			name:  "predicates",
+			input: `assignee_id:is:missing OR alias:IS:Empty OR details:is:null`,
+			expected: Or{
+				Left: Or{
+					Left:  Is{Field: "assignee_id", Predicate: IsMissing},
+					Right: Is{Field: "alias", Predicate: IsEmpty},
+				},
+				Right: Is{Field: "details", Predicate: IsNull},
+			},
+		},
+		{
+			name:     "quoted_predicate_is_a_value",
+			input:    `subject:"is:null" signature:is:"null"`,
+			expected: And{Left: Term{Field: "subject", Value: "is:null"}, Right: Is{Field: "signature", Predicate: IsNull}},
+		},
+		{
+			name:  "match_modes",
+			input: `name:nocase:"francis bailey" url:prefix:http://initech tags:GLOB:We?t* name:regex:"^(Fr|Cr)\w+ B" name:exact:is:null`,
+			expected: And{
+				Left: And{
+					Left: And{
+						Left: And{
+							Left:  Term{Field: "name", Value: "francis bailey", Mode: NoCase},
+							Right: Term{Field: "url", Value: "http://initech", Mode: Prefix},
+						},
+						Right: Term{Field: "tags", Value: "We?t*", Mode: Glob},
+					},
+					Right: Term{Field: "name", Value: `^(Fr|Cr)\w+ B`, Mode: Regex},
+				},
+				Right: Term{Field: "name", Value: "is:null", Mode: Exact},
+			},
+		},
+		{
+			name:     "regex_is_not_a_range",
+			input:    "name:regex:Fr..cis",
+			expected: Term{Field: "name", Value: "Fr..cis", Mode: Regex},
+		},
+		{
+			name:          "invalid_regex",
+			input:         `name:regex:"Fr(a"`,
+			expectedError: "syntax error at position 1 near \"name:regex:\\\"Fr(a\\\"\": invalid regular expression: error parsing regexp: missing closing ): `Fr(a`",
+		},
+		{
+			name:          "unknown_predicate",
+			input:         "alias:is:blank",
+			expectedError: `syntax error at position 1 near "alias:is:blank": unknown predicate is:blank, expected is:null, is:missing or is:empty`,
+		},
+		{
+			name:          "range_missing_value",
+			input:         "_id:>",
+			expectedError: `syntax error at position 1 near "_id:>": missing value to compare _id with`,
+		},
+		{
+			name:          "range_invalid_value",
+			input:         "status:open due_at:>tomorrow",
+			expectedError: `syntax error at position 13 near "due_at:>tomorrow": "tomorrow" is not a number or a timestamp like "2006-01-02T15:04:05 -07:00"`,
+		},
+		{
+			name:          "range_mixed_kinds",
+			input:         "_id:10..2016-01-01",
+			expectedError: `syntax error at position 1 near "_id:10..2016-01-01": cannot compare a number with a timestamp`,
+		},
+		{
+			name:          "range_reversed",
+			input:         "_id:20..10",
+			expectedError: `syntax error at position 1 near "_id:20..10": the start of the range is after its end`,
+		},
+		{
+			name:          "range_with_operator",
+			input:         "_id:>10..20",
+			expectedError: `syntax error at position 1 near "_id:>10..20": a range cannot have a comparison operator`,
+		},
+		{
+			name:          "text_range",
+			input:         "text:>a",
+			expectedError: `syntax error at position 1 near "text:>a": text queries cannot have operators, predicates or match modes`,
+		},
+		{
+			name:          "empty",
+			input:         "  ",
+			expectedError: `syntax error at position 3 near "end of query": empty query`,
+		},
+		{
+			name:          "unquoted_spaces",
+			input:         "name:Francis Bailey",
+			expectedError: `syntax error at position 14 near "Bailey": expected field:value, values containing spaces must be quoted`,
+		},
+		{
+			name:          "double_operator",
+			input:         "status:open AND AND priority:high",
+			expectedError: `syntax error at position 17 near "AND": unexpected AND, expected field:value`,
+		},
+		{
+			name:          "trailing_operator",
+			input:         "status:open OR",
+			expectedError: `syntax error at position 15 near "end of query": unexpected end of query, expected field:value`,
+		},
+		{
+			name:          "missing_closing_parenthesis",
+			input:         "(status:open OR status:closed",
+			expectedError: `syntax error at position 30 near "end of query": expected closing parenthesis`,
+		},
+		{
+			name:          "unexpected_closing_parenthesis",
+			input:         "status:open)",
+			expectedError: `syntax error at position 12 near ")": unexpected closing parenthesis`,
+		},
+		{
+			name:  "joins",
+			input: "organization.tags:West assignee.role:admin NOT submitter.organization.name:prefix:En organization.text:Aus",
+			expected: And{
+				Left: And{
+					Left: And{
+						Left:  Join{Relation: "organization", Expr: Term{Field: "tags", Value: "West"}},
+						Right: Join{Relation: "assignee", Expr: Term{Field: "role", Value: "admin"}},
+					},
+					Right: Not{Expr: Join{Relation: "submitter", Expr: Join{
+						Relation: "organization",
+						Expr:     Term{Field: "name", Value: "En", Mode: Prefix},
+					}}},
+				},
+				Right: Join{Relation: "organization", Expr: Text{Value: "Aus"}},
+			},
+		},
+		{
+			name:  "join_range_and_predicate",
+			input: "assignee._id:>10 OR organization.details:is:missing",
+			expected: Or{
+				Left:  Join{Relation: "assignee", Expr: Range{Field: "_id", Min: &Bound{Value: "10"}}},
+				Right: Join{Relation: "organization", Expr: Is{Field: "details", Predicate: IsMissing}},
+			},
+		},
+		{
+			name:          "join_missing_relation",
+			input:         ".tags:West",
+			expectedError: `syntax error at position 1 near ".tags:West": missing relation name before .tags`,
+		},
+		{
+			name:          "join_missing_field",
+			input:         "organization.:West",
+			expectedError: `syntax error at position 1 near "organization.:West": missing field name after organization.`,
+		},
+		{
+			name:          "missing_field",
+			input:         ":open",
+			expectedError: `syntax error at position 1 near ":open": missing field name before colon`,
+		},
+		{
+			name:          "unterminated_quote",
+			input:         `name:"Francis`,
+			expectedError: `syntax error at position 6 near "\"Francis": unterminated quoted string`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSyntaxError_Pointer(t *testing.T) {
+	_, err := Parse("tags:Ohio AND AND")
+
+	var syntaxErr *SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, "tags:Ohio AND AND\n              ^", syntaxErr.Pointer())
+}
+
+func TestExpr_String(t *testing.T) {
+	expr, err := Parse(`status:open AND NOT name:"Francis Bailey"`)
+	require.NoError(t, err)
+
+	assert.Equal(t, `(status:open AND NOT name:"Francis Bailey")`, expr.String())
+
+	for _, input := range []string{
+		`_id:10..20`,
+		`due_at:>="2016-08-01T00:00:00 -10:00"`,
+		`_id:>10`,
+		`_id:<10`,
+		`_id:<=10`,
+		`subject:"a..b"`,
+		`alias:"<b>"`,
+		`assignee_id:is:missing`,
+		`subject:"is:null"`,
+		`name:nocase:"francis bailey"`,
+		`name:"prefix:x"`,
+		`name:regex:"\\d+"`,
+		`assignee.organization.tags:West`,
+		`organization.text:"Aus Ltd"`,
+		`submitter._id:<=10`,
+	} {
+		expr, err := Parse(input)
+		require.NoError(t, err)
+		assert.Equal(t, input, expr.String())
+	}
+}
+
+func TestParseOrdered(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		kind     Kind
+	}{
+		{value: "42", expected: 42, kind: Number},
+		{value: "-1.5", expected: -1.5, kind: Number},
+		{value: "2016-04-28T11:19:34 -10:00", expected: 1461878374, kind: Timestamp},
+		{value: "2016-04-28T21:19:34Z", expected: 1461878374, kind: Timestamp},
+		{value: "2016-04-28", expected: 1461801600, kind: Timestamp},
+	}
+
+	for _, tt := range tests {
+		n, kind, ok := ParseOrdered(tt.value)
+		require.True(t, ok, tt.value)
+		assert.Equal(t, tt.expected, n, tt.value)
+		assert.Equal(t, tt.kind, kind, tt.value)
+	}
+
+	for _, value := range []string{"", "open", "+Inf", "NaN", "2016-04-28T11:19:34"} {
+		_, _, ok := ParseOrdered(value)
+		assert.False(t, ok, value)
+	}
+}
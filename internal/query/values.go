@@ -0,0 +1,75 @@
+package query
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// Kind is the type of the values that can be compared in a Range
+type Kind int
+
+// Kinds of ordered values
+const (
+	Number Kind = iota + 1
+	Timestamp
+)
+
+// String returns the name of the kind used in error messages
+func (k Kind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case Timestamp:
+		return "timestamp"
+	default:
+		return "unordered value"
+	}
+}
+
+// timeLayouts are the timestamp formats accepted in ranges. The first one is the format
+// used in the data, dates without a time are midnight UTC.
+var timeLayouts = []string{model.TimeLayout, time.RFC3339, "2006-01-02"}
+
+// ParseOrdered converts a number or a timestamp into a float64 that keeps the order
+// of the values of the same Kind. Timestamps are converted into seconds since the Unix
+// epoch so the same instant in different time zones is equal. The last value is false
+// when value is neither a number nor a timestamp.
+func ParseOrdered(value string) (float64, Kind, bool) {
+	// most values are words, skip them without trying to parse them
+	if value == "" || !isNumberStart(value[0]) {
+		return 0, 0, false
+	}
+
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsInf(n, 0) {
+			return 0, 0, false
+		}
+
+		return n, Number, true
+	}
+
+	if t, ok := ParseTime(value); ok {
+		return float64(t.Unix()) + float64(t.Nanosecond())/1e9, Timestamp, true
+	}
+
+	return 0, 0, false
+}
+
+// ParseTime parses a timestamp written in any of the formats accepted in ranges,
+// keeping its time zone. The last value is false when value is not a timestamp.
+func ParseTime(value string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func isNumberStart(c byte) bool {
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,95 @@
+package reader
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Types of the cells of CSV files, see CellTypes
+const (
+	CellNumber  = "number"
+	CellBoolean = "boolean"
+	// CellArray cells are JSON arrays e.g. ["West","Farley"]
+	CellArray = "array"
+)
+
+// CellTypes maps the fields of a CSV file to the type of their cells, the cells of any
+// other field are strings so values like phone numbers and zero padded codes are kept
+// as they are written. Cells that are not of the type of their field are kept as strings
+// too, so the record fails validation like a JSON file with the wrong type would.
+type CellTypes map[string]string
+
+// streamCSV reads a CSV file with a header row. Every row is converted into a record
+// using the header as field names, see parseCell for how values are typed.
+func streamCSV(r io.Reader, types CellTypes, fn RecordFunc) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+
+	// the header is overwritten by the next Read because of ReuseRecord
+	fields := append([]string(nil), header...)
+
+	for k := 0; ; k++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("record %d: %w", k, err)
+		}
+
+		record := make(map[string]interface{}, len(fields))
+		for i, cell := range row {
+			if cell == "" {
+				// empty cells are treated as missing fields
+				continue
+			}
+
+			record[fields[i]] = parseCell(cell, types[fields[i]])
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// parseCell converts a cell into the type its field has in JSON files, e.g. 101, true
+// or ["West","Farley"], null is a null value. Cells without a type or that are not
+// valid JSON of their type are strings.
+func parseCell(cell, cellType string) interface{} {
+	if cellType == "" {
+		return cell
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(cell), &v); err != nil {
+		return cell
+	}
+
+	switch v.(type) {
+	case nil:
+		return nil
+	case float64:
+		if cellType == CellNumber {
+			return v
+		}
+	case bool:
+		if cellType == CellBoolean {
+			return v
+		}
+	case []interface{}:
+		if cellType == CellArray {
+			return v
+		}
+	}
+
+	return cell
+}
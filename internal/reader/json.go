@@ -0,0 +1,67 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// streamJSONArray decodes a JSON array of objects one element at a time
+func streamJSONArray(r io.Reader, fn RecordFunc) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected a JSON array but got %v at offset %d", tok, dec.InputOffset())
+	}
+
+	for k := 0; dec.More(); k++ {
+		if err := decodeRecord(dec, k, fn); err != nil {
+			return err
+		}
+	}
+
+	// consume the closing bracket so a truncated file is reported as an error
+	_, err = dec.Token()
+
+	return err
+}
+
+// streamNDJSON decodes one JSON object per line
+func streamNDJSON(r io.Reader, fn RecordFunc) error {
+	dec := json.NewDecoder(r)
+
+	for k := 0; ; k++ {
+		err := decodeRecord(dec, k, fn)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeRecord decodes the next value of dec which must be an object and calls fn with it.
+// Returns io.EOF when there are no more values.
+func decodeRecord(dec *json.Decoder, k int, fn RecordFunc) error {
+	var record map[string]interface{}
+	if err := dec.Decode(&record); err != nil {
+		if err == io.EOF {
+			return err
+		}
+
+		return fmt.Errorf("record %d: %w", k, err)
+	}
+
+	if record == nil {
+		return fmt.Errorf("record %d: expected a JSON object but got null", k)
+	}
+
+	return fn(record)
+}
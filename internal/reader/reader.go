@@ -1,11 +1,54 @@
 package reader
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// progressInterval is the number of records read between progress reports
+const progressInterval = 10000
+
+// Supported file formats, any of them can be gzipped
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// gzipMagic are the first bytes of a gzip file
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// RecordFunc is called with every record read from a file
+type RecordFunc func(record map[string]interface{}) error
+
+// Progress describes how much of a file has been read so far
+type Progress struct {
+	Filename   string
+	Records    int
+	BytesRead  int64
+	TotalBytes int64
+	Done       bool
+}
+
+// Percent returns the percentage of the file that has been read
+func (p Progress) Percent() float64 {
+	if p.TotalBytes == 0 {
+		return 100
+	}
+
+	return float64(p.BytesRead) * 100 / float64(p.TotalBytes)
+}
+
+// ProgressFunc is called periodically while a file is being streamed
+type ProgressFunc func(Progress)
+
 // ReadJSONFile attempts to open a JSON filename and unmarshals its contents
 // into output
 func ReadJSONFile(filename string, output interface{}) error {
@@ -13,11 +56,155 @@ func ReadJSONFile(filename string, output interface{}) error {
 	if err != nil {
 		return err
 	}
+	defer f.Close()
+
+	return json.NewDecoder(f).Decode(output)
+}
+
+// StreamFile reads the records of filename one at a time and calls fn with each of
+// them, so the raw contents of the file are never held in memory. The file can be a
+// JSON array of objects, newline delimited JSON or CSV with a header, optionally
+// gzipped, see DetectFormat. The cells of CSV files are typed with types.
+// progress is optional and is called every progressInterval records and once the
+// whole file has been read.
+func StreamFile(filename string, types CellTypes, fn RecordFunc, progress ProgressFunc) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// progress is measured on the file so it is accurate for compressed files too
+	counter := &countingReader{r: f}
+	r := bufio.NewReader(counter)
+
+	magic, err := r.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	content := r
+	if bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		content = bufio.NewReader(gz)
+	}
 
-	b, err := io.ReadAll(f)
+	format, err := DetectFormat(filename, content)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(b, output)
+	t := &tracker{
+		fn:       fn,
+		progress: progress,
+		counter:  counter,
+		p:        Progress{Filename: filename, TotalBytes: info.Size()},
+	}
+
+	switch format {
+	case FormatJSON:
+		err = streamJSONArray(content, t.add)
+	case FormatNDJSON:
+		err = streamNDJSON(content, t.add)
+	case FormatCSV:
+		err = streamCSV(content, types, t.add)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	t.done()
+
+	return nil
+}
+
+// DetectFormat returns the format of filename based on its extension ignoring a
+// trailing .gz, e.g. users.ndjson.gz is FormatNDJSON. When the extension is not known
+// the first character of the content is used instead, [ for FormatJSON and { for
+// FormatNDJSON.
+func DetectFormat(filename string, content *bufio.Reader) (string, error) {
+	switch filepath.Ext(strings.TrimSuffix(strings.ToLower(filename), ".gz")) {
+	case ".json":
+		return FormatJSON, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+
+	for {
+		b, err := content.Peek(1)
+		if err != nil {
+			return "", fmt.Errorf("unknown format for %s: %w", filename, err)
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			if _, err := content.ReadByte(); err != nil {
+				return "", err
+			}
+		case '[':
+			return FormatJSON, nil
+		case '{':
+			return FormatNDJSON, nil
+		default:
+			return "", fmt.Errorf("unknown format for %s, use a .json, .ndjson, .jsonl or .csv extension", filename)
+		}
+	}
+}
+
+// tracker counts the records passed to fn and reports the progress
+type tracker struct {
+	fn       RecordFunc
+	progress ProgressFunc
+	counter  *countingReader
+	p        Progress
+}
+
+func (t *tracker) add(record map[string]interface{}) error {
+	if err := t.fn(record); err != nil {
+		return err
+	}
+
+	t.p.Records++
+	if t.progress != nil && t.p.Records%progressInterval == 0 {
+		t.p.BytesRead = t.counter.n
+		t.progress(t.p)
+	}
+
+	return nil
+}
+
+func (t *tracker) done() {
+	if t.progress == nil {
+		return
+	}
+
+	t.p.BytesRead = t.counter.n
+	t.p.Done = true
+	t.progress(t.p)
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
 }
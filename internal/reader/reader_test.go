@@ -1,6 +1,7 @@
 package reader
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,3 +47,127 @@ func TestReadJSONFile(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamFile(t *testing.T) {
+	names := []interface{}{"Francis Bailey", "Cross Barlow", "Ingrid Wagner"}
+
+	tests := []struct {
+		name          string
+		filename      string
+		expectedNames []interface{}
+		expectedError string
+	}{
+		{
+			name:          "json_array",
+			filename:      "testdata/array.json",
+			expectedNames: names,
+		},
+		{
+			name:          "gzipped_json_array",
+			filename:      "testdata/array.json.gz",
+			expectedNames: names,
+		},
+		{
+			name:          "ndjson",
+			filename:      "testdata/array.ndjson",
+			expectedNames: names,
+		},
+		{
+			name:          "gzipped_ndjson",
+			filename:      "testdata/array.ndjson.gz",
+			expectedNames: names,
+		},
+		{
+			name:          "ndjson_detected_from_content",
+			filename:      "testdata/sniffed.data",
+			expectedNames: names,
+		},
+		{
+			name:          "csv",
+			filename:      "testdata/array.csv",
+			expectedNames: names,
+		},
+		{
+			name:          "csv_wrong_number_of_fields",
+			filename:      "testdata/bad.csv",
+			expectedError: "record 0: record on line 2: wrong number of fields",
+		},
+		{
+			name:          "not_an_array",
+			filename:      "testdata/valid.json",
+			expectedError: "expected a JSON array but got { at offset 1",
+		},
+		{
+			name:          "truncated_file",
+			filename:      "testdata/truncated.json",
+			expectedNames: []interface{}{"Francis Bailey"},
+			expectedError: "record 1: unexpected EOF",
+		},
+		{
+			name:          "unknown_format",
+			filename:      "testdata/unknown.txt",
+			expectedError: "unknown format for testdata/unknown.txt, use a .json, .ndjson, .jsonl or .csv extension",
+		},
+		{
+			name:          "file_does_not_exist",
+			filename:      "testdata/unknown.json",
+			expectedError: "no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []interface{}
+			var progress []Progress
+
+			err := StreamFile(tt.filename, nil, func(record map[string]interface{}) error {
+				names = append(names, record["name"])
+				return nil
+			}, func(p Progress) {
+				progress = append(progress, p)
+			})
+
+			assert.Equal(t, tt.expectedNames, names)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, progress, 1)
+			assert.True(t, progress[0].Done)
+			assert.Equal(t, len(tt.expectedNames), progress[0].Records)
+			assert.Equal(t, progress[0].TotalBytes, progress[0].BytesRead)
+		})
+	}
+}
+
+func TestStreamFile_CSVTypes(t *testing.T) {
+	var records []map[string]interface{}
+	types := CellTypes{"_id": CellNumber, "active": CellBoolean, "tags": CellArray, "organization_id": CellNumber}
+	err := StreamFile("testdata/array.csv", types, func(record map[string]interface{}) error {
+		records = append(records, record)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+
+	// cells of fields without a type are strings even when they look like numbers
+	expected := []map[string]interface{}{
+		{"_id": float64(1), "name": "Francis Bailey", "active": true, "tags": []interface{}{"Leola", "Veguita"}, "phone": "0412345678", "organization_id": nil},
+		{"_id": float64(2), "name": "Cross Barlow", "active": false, "tags": []interface{}{}, "alias": "Miss Joni", "organization_id": float64(101)},
+		{"_id": float64(3), "name": "Ingrid Wagner", "alias": "1984", "phone": "true", "organization_id": "unknown"},
+	}
+	assert.Equal(t, expected, records)
+}
+
+func TestStreamFile_CallbackError(t *testing.T) {
+	calls := 0
+	err := StreamFile("testdata/array.json", nil, func(record map[string]interface{}) error {
+		calls++
+		return errors.New("stop")
+	}, nil)
+
+	require.EqualError(t, err, "stop")
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,172 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFile replaces the contents of filename with the records, in the same format the
+// file is read in, see DetectFormat. JSON arrays are indented like the Zendesk exports.
+// The records are written to a temporary file in the same directory that is renamed
+// once it is complete, so readers never see a file half written. CSV files can't be
+// written since records can have nested values.
+func WriteFile(filename string, records []map[string]interface{}) error {
+	format, gzipped, err := fileFormat(filename)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatCSV {
+		return fmt.Errorf("cannot write %s, only JSON and newline delimited JSON files can be written", filename)
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	err = writeRecords(tmp, format, gzipped, records)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+func writeRecords(w io.Writer, format string, gzipped bool, records []map[string]interface{}) error {
+	buf := bufio.NewWriter(w)
+
+	out := io.Writer(buf)
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(buf)
+		out = gz
+	}
+
+	var err error
+	if format == FormatNDJSON {
+		err = writeNDJSON(out, records)
+	} else {
+		err = writeJSONArray(out, records)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+
+	return buf.Flush()
+}
+
+func writeJSONArray(w io.Writer, records []map[string]interface{}) error {
+	if len(records) == 0 {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+
+	if _, err := io.WriteString(w, "[\n  "); err != nil {
+		return err
+	}
+
+	for k, record := range records {
+		if k > 0 {
+			if _, err := io.WriteString(w, ",\n  "); err != nil {
+				return err
+			}
+		}
+
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("  ", "  ")
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+
+		if _, err := w.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n"))); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+func writeNDJSON(w io.Writer, records []map[string]interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileFormat returns the format of filename and whether it is gzipped, reading the
+// beginning of the file like StreamFile does
+func fileFormat(filename string) (string, bool, error) {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		// new files are JSON arrays unless the extension says otherwise
+		format, err := DetectFormat(filename, bufio.NewReader(strings.NewReader("[")))
+		return format, strings.HasSuffix(strings.ToLower(filename), ".gz"), err
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, err := r.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+
+	gzipped := bytes.Equal(magic, gzipMagic)
+	content := r
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return "", false, err
+		}
+		defer gz.Close()
+
+		content = bufio.NewReader(gz)
+	}
+
+	format, err := DetectFormat(filename, content)
+	return format, gzipped, err
+}
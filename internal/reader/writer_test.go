@@ -0,0 +1,112 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	records := []map[string]interface{}{
+		{"_id": float64(1), "name": "Francis <Bailey>", "tags": []interface{}{"West"}},
+		{"_id": float64(2), "alias": nil, "tags": []interface{}{}},
+	}
+
+	tests := []struct {
+		name         string
+		filename     string
+		existing     string
+		expectedFile string
+	}{
+		{
+			name:     "json",
+			filename: "users.json",
+			expectedFile: `[
+  {
+    "_id": 1,
+    "name": "Francis <Bailey>",
+    "tags": [
+      "West"
+    ]
+  },
+  {
+    "_id": 2,
+    "alias": null,
+    "tags": []
+  }
+]
+`,
+		},
+		{
+			name:     "ndjson",
+			filename: "users.jsonl",
+			expectedFile: `{"_id":1,"name":"Francis <Bailey>","tags":["West"]}
+{"_id":2,"alias":null,"tags":[]}
+`,
+		},
+		{
+			name:     "sniffed_ndjson",
+			filename: "users.data",
+			existing: `{"_id": 3}`,
+			expectedFile: `{"_id":1,"name":"Francis <Bailey>","tags":["West"]}
+{"_id":2,"alias":null,"tags":[]}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.filename)
+			if tt.existing != "" {
+				require.NoError(t, os.WriteFile(filename, []byte(tt.existing), 0o600))
+			}
+
+			require.NoError(t, WriteFile(filename, records))
+
+			content, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedFile, string(content))
+
+			var read []map[string]interface{}
+			require.NoError(t, StreamFile(filename, nil, func(record map[string]interface{}) error {
+				read = append(read, record)
+				return nil
+			}, nil))
+			assert.Equal(t, records, read)
+
+			// the temporary file is renamed
+			files, err := os.ReadDir(filepath.Dir(filename))
+			require.NoError(t, err)
+			assert.Len(t, files, 1)
+		})
+	}
+}
+
+func TestWriteFile_Gzip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "users.ndjson.gz")
+	records := []map[string]interface{}{{"_id": float64(1)}}
+	require.NoError(t, WriteFile(filename, records))
+
+	var read []map[string]interface{}
+	require.NoError(t, StreamFile(filename, nil, func(record map[string]interface{}) error {
+		read = append(read, record)
+		return nil
+	}, nil))
+	assert.Equal(t, records, read)
+}
+
+func TestWriteFile_CSV(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(filename, []byte("_id\n1\n"), 0o600))
+
+	err := WriteFile(filename, []map[string]interface{}{{"_id": float64(2)}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only JSON and newline delimited JSON files can be written")
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "_id\n1\n", string(content))
+}
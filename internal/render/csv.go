@@ -0,0 +1,75 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// csvRenderer writes a header with the columns of all results followed by one
+// row per result. Nothing is written when there are no results.
+type csvRenderer struct{}
+
+func (c *csvRenderer) Render(w io.Writer, _ string, results []model.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	cols := columns(results)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+
+	row := make([]string, len(cols))
+	for _, result := range results {
+		rec := Record(result)
+		for k, col := range cols {
+			row[k] = formatCell(rec[col])
+		}
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// formatCell converts a value into a CSV cell. Arrays are joined with a semicolon.
+func formatCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return strings.Join(v, ";")
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, formatCell(elem))
+		}
+
+		return strings.Join(elems, ";")
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return string(b)
+	}
+}
@@ -0,0 +1,32 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// jsonRenderer writes the results as an indented JSON array or, when newlineDelimited
+// is set, as one JSON object per line so they can be streamed into tools like jq.
+type jsonRenderer struct {
+	newlineDelimited bool
+}
+
+func (j *jsonRenderer) Render(w io.Writer, _ string, results []model.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if !j.newlineDelimited {
+		enc.SetIndent("", "  ")
+		return enc.Encode(Records(results))
+	}
+
+	for _, result := range results {
+		if err := enc.Encode(Record(result)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
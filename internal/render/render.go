@@ -0,0 +1,103 @@
+// Package render writes search results in human or machine readable formats.
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// Supported formats
+const (
+	Table  = "table"
+	JSON   = "json"
+	NDJSON = "ndjson"
+	CSV    = "csv"
+	YAML   = "yaml"
+)
+
+// Formats lists the names accepted by New
+var Formats = []string{Table, JSON, NDJSON, CSV, YAML}
+
+// Renderer writes the results of searching an entity to w
+type Renderer interface {
+	Render(w io.Writer, entity string, results []model.Result) error
+}
+
+// New returns the Renderer for format
+func New(format string) (Renderer, error) {
+	switch format {
+	case Table:
+		return &TableRenderer{}, nil
+	case JSON:
+		return &jsonRenderer{}, nil
+	case NDJSON:
+		return &jsonRenderer{newlineDelimited: true}, nil
+	case CSV:
+		return &csvRenderer{}, nil
+	case YAML:
+		return &yamlRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, must be one of %v", format, Formats)
+	}
+}
+
+// Record flattens a result into a single map containing the fields of the record
+// and the fields of its related entities.
+func Record(result model.Result) map[string]interface{} {
+	fields := result.Fields()
+	related := result.Related()
+
+	rec := make(map[string]interface{}, len(fields)+len(related))
+	for k, v := range fields {
+		rec[k] = v
+	}
+
+	for _, field := range related {
+		rec[field.Name] = field.Value
+	}
+
+	return rec
+}
+
+// Records flattens all results, see Record
+func Records(results []model.Result) []map[string]interface{} {
+	recs := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		recs = append(recs, Record(result))
+	}
+
+	return recs
+}
+
+// columns returns the union of the fields of all results sorted by name, followed
+// by the union of the fields of the related entities in the order they are displayed.
+func columns(results []model.Result) []string {
+	seen := map[string]bool{}
+
+	var fields []string
+	for _, result := range results {
+		for k := range result.Fields() {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+
+	sort.Strings(fields)
+
+	// results may not have the same related fields, e.g. only ranked results have a score
+	for _, result := range results {
+		for _, field := range result.Related() {
+			if !seen[field.Name] {
+				seen[field.Name] = true
+				fields = append(fields, field.Name)
+			}
+		}
+	}
+
+	return fields
+}
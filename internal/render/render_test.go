@@ -0,0 +1,246 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+var testResults = []model.Result{
+	model.UserResult{
+		User: model.User{
+			"_id":  float64(1),
+			"name": "Francis Bailey",
+			"tags": []interface{}{"Leola", "Veguita"},
+		},
+		OrganizationName: "Enthaze",
+		SubmittedTickets: []string{"A Problem in Guyana"},
+	},
+	model.UserResult{
+		User: model.User{
+			"_id":    float64(2),
+			"name":   "Cross Barlow",
+			"active": true,
+		},
+	},
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format   string
+		results  []model.Result
+		expected string
+	}{
+		{
+			format:  JSON,
+			results: testResults[:1],
+			expected: `[
+  {
+    "_id": 1,
+    "assigned_tickets": null,
+    "name": "Francis Bailey",
+    "organization_name": "Enthaze",
+    "submitted_tickets": [
+      "A Problem in Guyana"
+    ],
+    "tags": [
+      "Leola",
+      "Veguita"
+    ]
+  }
+]
+`,
+		},
+		{
+			format:   JSON,
+			expected: "[]\n",
+		},
+		{
+			format:  NDJSON,
+			results: testResults,
+			expected: `{"_id":1,"assigned_tickets":null,"name":"Francis Bailey","organization_name":"Enthaze","submitted_tickets":["A Problem in Guyana"],"tags":["Leola","Veguita"]}
+{"_id":2,"active":true,"assigned_tickets":null,"name":"Cross Barlow","organization_name":"","submitted_tickets":null}
+`,
+		},
+		{
+			format:  CSV,
+			results: testResults,
+			expected: `_id,active,name,tags,organization_name,submitted_tickets,assigned_tickets
+1,,Francis Bailey,Leola;Veguita,Enthaze,A Problem in Guyana,
+2,true,Cross Barlow,,,,
+`,
+		},
+		{
+			format:  YAML,
+			results: testResults[:1],
+			expected: `- _id: 1
+  assigned_tickets: []
+  name: Francis Bailey
+  organization_name: Enthaze
+  submitted_tickets:
+  - A Problem in Guyana
+  tags:
+  - Leola
+  - Veguita
+`,
+		},
+		{
+			format:   Table,
+			expected: "No results found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			r, err := New(tt.format)
+			require.NoError(t, err)
+
+			buf := &bytes.Buffer{}
+			err = r.Render(buf, "users", tt.results)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
+
+func TestRender_Table(t *testing.T) {
+	r, err := New(Table)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = r.Render(buf, "users", testResults)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "Francis Bailey")
+	assert.Contains(t, out, "organization_name   Enthaze")
+	assert.Contains(t, out, "Total users found: 2\n")
+	assert.NotContains(t, out, model.ScoreField)
+
+	buf.Reset()
+	require.NoError(t, (&TableRenderer{Total: 21}).Render(buf, "users", testResults))
+	assert.Contains(t, buf.String(), "Total users found: 21\n")
+}
+
+func TestRender_Score(t *testing.T) {
+	results := []model.Result{
+		model.TicketResult{Ticket: model.Ticket{"_id": "a", "subject": "Printer broken"}, Score: 2.34567},
+	}
+
+	r, err := New(NDJSON)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, r.Render(buf, "tickets", results))
+	assert.Equal(t, `{"_id":"a","_score":2.346,"assignee_name":"","organization_name":"","subject":"Printer broken","submitter_name":""}`+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&TableRenderer{}).Render(buf, "tickets", results))
+	assert.Contains(t, buf.String(), "_score              2.346\n")
+
+	// the first result may not be ranked, e.g. after sorting
+	results = append([]model.Result{model.TicketResult{Ticket: model.Ticket{"_id": "b"}}}, results...)
+	r, err = New(CSV)
+	require.NoError(t, err)
+
+	buf.Reset()
+	require.NoError(t, r.Render(buf, "tickets", results))
+	assert.Equal(t, "_id,subject,organization_name,submitter_name,assignee_name,_score\n"+
+		"b,,,,,\n"+
+		"a,Printer broken,,,,2.346\n", buf.String())
+}
+
+func TestRender_Entity(t *testing.T) {
+	results := []model.Result{
+		model.EntityResult{
+			Record:    map[string]interface{}{"id": float64(1), "name": "Support Agents", "organization_id": float64(101)},
+			Display:   []string{"id", "name", "description"},
+			Relations: []model.Field{{Name: "organization_name", Value: "Enthaze"}, {Name: "brands_name", Value: []string{"Kage", "Zolar"}}},
+			Score:     1.23456,
+		},
+		model.EntityResult{Record: map[string]interface{}{"name": "Billing", "id": float64(2)}},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, (&TableRenderer{}).Render(buf, "groups", results))
+	assert.Equal(t, `
+id                  1
+name                Support Agents
+description         `+`
+organization_name   Enthaze
+brands_name_0       Kage
+brands_name_1       Zolar
+_score              1.235
+
+id                  2
+name                Billing
+Total groups found: 2
+`, buf.String())
+
+	err := (&TableRenderer{}).Render(buf, "groups", []model.Result{model.UserResult{}})
+	require.EqualError(t, err, "no template for entity: groups")
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, err := New("xml")
+	require.EqualError(t, err, `unknown format "xml", must be one of [table json ndjson csv yaml]`)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestEncode(t *testing.T) {
+	type bucket struct {
+		Value string `json:"value" yaml:"value"`
+		Count int    `json:"count" yaml:"count"`
+	}
+
+	buckets := []bucket{{Value: "open", Count: 2}, {Value: "closed", Count: 1}}
+	rows := [][]string{{"status", "count"}, {"open", "2"}, {"closed", "1"}}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "json",
+			format:   JSON,
+			expected: "[\n  {\n    \"value\": \"open\",\n    \"count\": 2\n  },\n  {\n    \"value\": \"closed\",\n    \"count\": 1\n  }\n]\n",
+		},
+		{
+			name:     "ndjson_line_per_element",
+			format:   NDJSON,
+			expected: "{\"value\":\"open\",\"count\":2}\n{\"value\":\"closed\",\"count\":1}\n",
+		},
+		{
+			name:     "yaml",
+			format:   YAML,
+			expected: "- value: open\n  count: 2\n- value: closed\n  count: 1\n",
+		},
+		{
+			name:     "csv",
+			format:   CSV,
+			expected: "status,count\nopen,2\nclosed,1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, tt.format, buckets, rows))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+
+	assert.EqualError(t, Encode(failingWriter{}, CSV, buckets, rows), "disk full")
+	assert.EqualError(t, Encode(&bytes.Buffer{}, Table, buckets, rows), "cannot encode values in the table format")
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+var tableTemplates = map[string]*template.Template{
+	"organizations": model.OrgResultTemplate,
+	"users":         model.UserResultTemplate,
+	"tickets":       model.TicketResultTemplate,
+}
+
+// TableRenderer renders every result using the templates defined in the model package.
+// The results of the entities of the entities config are rendered one field per line
+// in the same layout, see model.EntityResult.
+type TableRenderer struct {
+	// Total is the number of results found when only a page of them is rendered,
+	// the number of results rendered when it is zero
+	Total int
+}
+
+// Render writes every result followed by the total number of results found
+func (t *TableRenderer) Render(w io.Writer, entity string, results []model.Result) error {
+	if len(results) == 0 {
+		_, err := fmt.Fprintln(w, "No results found")
+		return err
+	}
+
+	tmpl, ok := tableTemplates[entity]
+	for _, result := range results {
+		var err error
+		switch result := result.(type) {
+		case model.EntityResult:
+			err = renderEntity(w, result)
+		default:
+			if !ok {
+				return fmt.Errorf("no template for entity: %s", entity)
+			}
+
+			err = tmpl.Execute(w, result)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	total := t.Total
+	if total < len(results) {
+		total = len(results)
+	}
+
+	_, err := fmt.Fprintf(w, "Total %s found: %d\n", entity, total)
+
+	return err
+}
+
+// renderEntity writes the display fields of the record followed by the fields of the
+// related records, listing every value of the ones with many like the templates do
+func renderEntity(w io.Writer, result model.EntityResult) error {
+	fields := result.Display
+	if len(fields) == 0 {
+		for field := range result.Record {
+			fields = append(fields, field)
+		}
+
+		sort.Strings(fields)
+	}
+
+	lines := []string{""}
+	for _, field := range fields {
+		lines = append(lines, tableLine(field, result.Record[field]))
+	}
+
+	for _, field := range result.Relations {
+		values, ok := field.Value.([]string)
+		if !ok {
+			lines = append(lines, tableLine(field.Name, field.Value))
+			continue
+		}
+
+		for k, value := range values {
+			lines = append(lines, tableLine(fmt.Sprintf("%s_%d", field.Name, k), value))
+		}
+	}
+
+	if result.Score != 0 {
+		lines = append(lines, tableLine(model.ScoreField, fmt.Sprintf("%.3f", result.Score)))
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+
+	return err
+}
+
+// tableLine writes the name padded like the templates, missing values are left empty
+func tableLine(name string, v interface{}) string {
+	if v == nil {
+		v = ""
+	}
+
+	return fmt.Sprintf("%-19s %v", name, v)
+}
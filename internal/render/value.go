@@ -0,0 +1,59 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Encode writes a value other than search results, e.g. statistics, in one of the
+// machine readable formats. JSON is indented, NDJSON has a line per element when v is
+// a slice and CSV has the rows, the first one being the header. Tables are printed by
+// the callers.
+func Encode(w io.Writer, format string, v interface{}, rows [][]string) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case NDJSON:
+		return encodeLines(w, v)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+
+		return enc.Close()
+	case CSV:
+		return csv.NewWriter(w).WriteAll(rows)
+	default:
+		return fmt.Errorf("cannot encode values in the %s format", format)
+	}
+}
+
+// encodeLines writes every element of a slice as a JSON object per line, and any other
+// value in a single line
+func encodeLines(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	elems := reflect.ValueOf(v)
+	if elems.Kind() != reflect.Slice {
+		return enc.Encode(v)
+	}
+
+	for k := 0; k < elems.Len(); k++ {
+		if err := enc.Encode(elems.Index(k).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package render
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+// yamlRenderer writes the results as a YAML sequence
+type yamlRenderer struct{}
+
+func (y *yamlRenderer) Render(w io.Writer, _ string, results []model.Result) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(Records(results)); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
@@ -0,0 +1,219 @@
+// Package server exposes the app.Storage over a local HTTP JSON API.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jaimem88/zearch/internal/app"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/render"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+// Server handles the following endpoints:
+//
+//	GET /{entity}?field=name&value=Enthaze  search by field and value
+//	GET /{entity}?field=name&value=En&match=prefix
+//	                                        search by field using a match mode
+//	GET /{entity}?query=status:open         search by query
+//	GET /{entity}?...&sort=priority:desc&limit=10&offset=20
+//	                                        sort and paginate a search
+//	GET /{entity}/{id}                      get a single record by its ID field
+//	GET /fields                             searchable fields per entity
+//	GET /schema                             types and counts of the fields per entity
+//
+// where entity is one of organizations, users, tickets or the entities of the
+// entities config, see store.EntityConfig.
+type Server struct {
+	store app.Storage
+	mux   *http.ServeMux
+}
+
+// New creates a Server that searches the store
+func New(store app.Storage) *Server {
+	s := &Server{
+		store: store,
+		mux:   http.NewServeMux(),
+	}
+
+	for _, config := range store.Entities() {
+		s.mux.Handle("/"+config.Name, s.searchHandler(config.Name))
+		s.mux.Handle("/"+config.Name+"/", s.getHandler(config.Name, config.IDField))
+	}
+
+	s.mux.HandleFunc("/fields", s.handleFields)
+	s.mux.HandleFunc("/schema", s.handleSchema)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+	})
+
+	return s
+}
+
+// ServeHTTP only allows GET requests and routes them to the handlers of the Server
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) searchHandler(entity string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+
+		var q query.Expr
+		switch {
+		case params.Get("query") != "" && params.Get("field") != "":
+			writeError(w, http.StatusBadRequest, errors.New("query and field cannot be used together"))
+			return
+		case params.Get("query") != "":
+			expr, err := query.Parse(params.Get("query"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			q = expr
+		case params.Get("field") != "":
+			mode, err := query.ParseMode(params.Get("match"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			term := query.Term{Field: params.Get("field"), Value: params.Get("value"), Mode: mode}
+			if _, err := term.Matcher(); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			q = term
+		default:
+			writeError(w, http.StatusBadRequest, errors.New("field or query parameter is required"))
+			return
+		}
+
+		page, err := parsePage(params)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		results, err := s.store.Search(entity, q)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, errorResponse{
+				Error:       fmt.Sprintf("%s %s", entity, store.ErrNotFound),
+				Suggestions: app.Suggestions(s.store, entity, q),
+			})
+		case errors.Is(err, store.ErrInvalidQuery):
+			writeError(w, http.StatusBadRequest, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			total := len(results)
+			w.Header().Set("X-Total-Count", strconv.Itoa(total))
+			if next, ok := page.Next(total); ok {
+				params.Set("offset", strconv.Itoa(next))
+				w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, params.Encode()))
+			}
+
+			writeJSON(w, http.StatusOK, render.Records(page.Apply(results)))
+		}
+	}
+}
+
+// parsePage reads the sort, limit and offset parameters
+func parsePage(params url.Values) (app.Page, error) {
+	var page app.Page
+
+	keys, err := app.ParseSort(params.Get("sort"))
+	if err != nil {
+		return page, err
+	}
+
+	page.Sort = keys
+
+	if page.Limit, err = parseCount(params, "limit"); err != nil {
+		return page, err
+	}
+
+	if page.Offset, err = parseCount(params, "offset"); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+// parseCount reads an optional parameter that must be zero or a positive number
+func parseCount(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+
+	return n, nil
+}
+
+func (s *Server) getHandler(entity, idField string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/"+entity+"/")
+		if id == "" || strings.Contains(id, "/") {
+			writeError(w, http.StatusNotFound, store.ErrNotFound)
+			return
+		}
+
+		results, err := s.store.Search(entity, query.Term{Field: idField, Value: id})
+		switch {
+		case errors.Is(err, store.ErrNotFound), err == nil && len(results) == 0:
+			writeError(w, http.StatusNotFound, fmt.Errorf("%s %s %w", entity, id, store.ErrNotFound))
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusOK, render.Record(results[0]))
+		}
+	}
+}
+
+func (s *Server) handleFields(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.GetSearchableFields())
+}
+
+func (s *Server) handleSchema(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.store.Schema())
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// Suggestions are queries to try when nothing was found, see app.Suggestions
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	// the status has been written already, the client will get a truncated body
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/store"
+)
+
+func TestServer(t *testing.T) {
+	s := New(store.New(
+		model.Organizations{
+			{"_id": float64(101), "name": "Enthaze", "tags": []interface{}{"West"}},
+		},
+		model.Users{
+			{"_id": float64(1), "name": "Francis Bailey", "organization_id": float64(101)},
+		},
+		model.Tickets{
+			{"_id": "27c447d9", "subject": "A Problem in Guyana", "status": "open", "submitter_id": float64(1)},
+		},
+	))
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "search_by_field",
+			target:         "/organizations?field=name&value=Enthaze",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"_id":101,"name":"Enthaze","tags":["West"],"ticket_subjects":[],"user_names":["Francis Bailey"]}]`,
+		},
+		{
+			name:           "search_by_query",
+			target:         "/tickets?query=status:open+AND+NOT+subject:other",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"_id":"27c447d9","assignee_name":"","organization_name":"","status":"open","subject":"A Problem in Guyana","submitter_id":1,"submitter_name":"Francis Bailey"}]`,
+		},
+		{
+			name:           "search_not_found",
+			target:         "/users?field=name&value=Nobody",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"users not found"}`,
+		},
+		{
+			name:           "search_not_found_suggestions",
+			target:         "/users?query=name:%22Fransis%20Baily%22",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"users not found","suggestions":["name:\"Francis Bailey\""]}`,
+		},
+		{
+			name:           "search_fuzzy",
+			target:         "/users?field=name&value=fransis&match=fuzzy",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"_id":1,"name":"Francis Bailey","organization_id":101,"organization_name":"Enthaze","submitted_tickets":["A Problem in Guyana"],"assigned_tickets":[],"_score":0.5}]`,
+		},
+		{
+			name:           "search_syntax_error",
+			target:         "/users?query=name:x+AND",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"syntax error at position 11 near \"end of query\": unexpected end of query, expected field:value"}`,
+		},
+		{
+			name:           "search_unknown_relation",
+			target:         "/tickets?query=foo.bar:1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"unknown relation \"foo\", must be one of assignee, organization, submitter"}`,
+		},
+		{
+			name:           "search_without_parameters",
+			target:         "/users",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"field or query parameter is required"}`,
+		},
+		{
+			name:           "get_by_id",
+			target:         "/users/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"_id":1,"assigned_tickets":[],"name":"Francis Bailey","organization_id":101,"organization_name":"Enthaze","submitted_tickets":["A Problem in Guyana"]}`,
+		},
+		{
+			name:           "get_by_id_not_found",
+			target:         "/tickets/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"tickets unknown not found"}`,
+		},
+		{
+			name:           "fields",
+			target:         "/fields",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"organizations":["_id","name","tags"],"tickets":["_id","status","subject","submitter_id"],"users":["_id","name","organization_id"]}`,
+		},
+		{
+			name:           "schema",
+			target:         "/schema",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{
+				"organizations":[
+					{"name":"_id","types":["number"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"name","types":["string"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"tags","types":["array"],"records":1,"nulls":0,"missing":0,"distinct":1}
+				],
+				"users":[
+					{"name":"_id","types":["number"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"name","types":["string"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"organization_id","types":["number"],"records":1,"nulls":0,"missing":0,"distinct":1}
+				],
+				"tickets":[
+					{"name":"_id","types":["string"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"status","types":["string"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"subject","types":["string"],"records":1,"nulls":0,"missing":0,"distinct":1},
+					{"name":"submitter_id","types":["number"],"records":1,"nulls":0,"missing":0,"distinct":1}
+				]
+			}`,
+		},
+		{
+			name:           "unknown_path",
+			target:         "/groups",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":"unknown path /groups"}`,
+		},
+		{
+			name:           "method_not_allowed",
+			method:         http.MethodPost,
+			target:         "/fields",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `{"error":"method POST not allowed"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(method, tt.target, nil))
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestServer_Page(t *testing.T) {
+	s := New(store.New(nil, model.Users{
+		{"_id": float64(1), "name": "Francis Bailey", "role": "admin"},
+		{"_id": float64(2), "name": "Cross Barlow", "role": "admin"},
+		{"_id": float64(3), "name": "Rose Newton", "role": "admin"},
+	}, nil))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=role&value=admin&sort=name:desc&limit=2", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</users?field=role&limit=2&offset=2&sort=name%3Adesc&value=admin>; rel="next"`, rec.Header().Get("Link"))
+	assert.JSONEq(t, `[
+		{"_id":3,"name":"Rose Newton","role":"admin","organization_name":"","submitted_tickets":[],"assigned_tickets":[]},
+		{"_id":1,"name":"Francis Bailey","role":"admin","organization_name":"","submitted_tickets":[],"assigned_tickets":[]}
+	]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=role&value=admin&limit=2&offset=2", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Link"))
+	assert.JSONEq(t, `[{"_id":3,"name":"Rose Newton","role":"admin","organization_name":"","submitted_tickets":[],"assigned_tickets":[]}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=role&value=admin&limit=-1", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"limit must be a positive number"}`, rec.Body.String())
+}
+
+func TestServer_Match(t *testing.T) {
+	s := New(store.New(nil, model.Users{
+		{"_id": float64(1), "name": "Francis Bailey"},
+		{"_id": float64(2), "name": "Cross Barlow"},
+	}, nil))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=name&value=cross&match=PREFIX", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=name&value=%5Ecross&match=regex", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=name&value=*B?rlow&match=glob", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"_id":2,"name":"Cross Barlow","organization_name":"","submitted_tickets":[],"assigned_tickets":[]}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=name&value=Fr&match=similar", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"unknown match mode \"similar\", must be one of exact, nocase, prefix, glob, regex, fuzzy"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users?field=name&value=(&match=regex", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_Entities(t *testing.T) {
+	b := store.NewBuilder()
+	require.NoError(t, b.SetEntities([]store.EntityConfig{{
+		Name:    "brands",
+		File:    "brands.json",
+		IDField: "subdomain",
+		IDType:  model.IDString,
+		Relations: []store.RelationConfig{
+			{Name: "organization", Link: "organization_id -> organizations._id", Show: "name"},
+		},
+	}}))
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze"}))
+	require.NoError(t, b.Add("brands", map[string]interface{}{"subdomain": "kage", "organization_id": float64(101)}))
+	s := New(b.Build())
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/brands?query=organization.name:Enthaze", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"subdomain":"kage","organization_id":101,"organization_name":"Enthaze"}]`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/brands/kage", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"subdomain":"kage","organization_id":101,"organization_name":"Enthaze"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/brands/zolar", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"brands zolar not found"}`, rec.Body.String())
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+// Intervals of the date histograms
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// Intervals lists the intervals a date histogram can group timestamps by
+var Intervals = []string{Day, Week, Month}
+
+// Aggregation describes the statistics to compute for a field of an entity
+type Aggregation struct {
+	// Field is aggregated, it can be a field of a related entity e.g. organization.name
+	Field string
+	// Query selects the records aggregated, all of them when nil
+	Query query.Expr
+	// Top keeps the N most frequent values, all of them when 0
+	Top int
+	// Interval groups the timestamps of the field by Day, Week or Month instead of
+	// counting every value
+	Interval string
+}
+
+// Bucket is the number of records with a value, or with a timestamp within an interval
+type Bucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// NumberStats summarizes the numeric values of a field
+type NumberStats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Sum   float64 `json:"sum"`
+	Avg   float64 `json:"avg"`
+}
+
+// Stats is the result of an Aggregation. Records with an array field are counted once
+// per element, so the counts of the buckets can add up to more than Records.
+type Stats struct {
+	Entity   string `json:"entity"`
+	Field    string `json:"field"`
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// Records is the number of records aggregated and Missing how many of them have
+	// no value in the field
+	Records int `json:"records"`
+	Missing int `json:"missing"`
+	// Distinct is the number of buckets before keeping the Top ones
+	Distinct int      `json:"distinct"`
+	Buckets  []Bucket `json:"buckets"`
+	// Numbers is nil when the field has no numbers
+	Numbers *NumberStats `json:"numbers,omitempty" yaml:"numbers,omitempty"`
+}
+
+// Aggregate computes the statistics of a field of the records of the entity matched
+// by the query of the aggregation. Values are counted from the inverted index, so
+// it doesn't go through the records. Buckets are sorted by count, or in chronological
+// order for date histograms.
+func (s *Storage) Aggregate(entity string, agg Aggregation) (Stats, error) {
+	stats := Stats{Entity: entity, Field: agg.Field, Interval: agg.Interval}
+
+	ei, ok := s.index(entity)
+	if !ok {
+		return stats, invalidQuery("unknown entity: %s", entity)
+	}
+
+	if agg.Interval != "" && bucketKey(time.Time{}, agg.Interval) == "" {
+		return stats, invalidQuery("unknown interval %q, must be one of %s", agg.Interval, strings.Join(Intervals, ", "))
+	}
+
+	records, err := ei.aggregated(agg.Query)
+	if err != nil {
+		return stats, err
+	}
+
+	values, err := ei.valueIDs(agg.Field)
+	if err != nil {
+		return stats, err
+	}
+
+	counts, found, numbers := countValues(values, records, agg.Interval)
+
+	stats.Records = len(records)
+	stats.Missing = len(records) - len(found)
+	stats.Distinct = len(counts)
+	stats.Buckets = sortBuckets(counts)
+	if agg.Top > 0 && len(stats.Buckets) > agg.Top {
+		stats.Buckets = stats.Buckets[:agg.Top]
+	}
+
+	// the most frequent intervals are picked before they are put in order
+	if agg.Interval != "" {
+		sort.Slice(stats.Buckets, func(i, j int) bool {
+			return stats.Buckets[i].Value < stats.Buckets[j].Value
+		})
+	}
+
+	if numbers.Count > 0 {
+		numbers.Avg = numbers.Sum / float64(numbers.Count)
+		stats.Numbers = &numbers
+	}
+
+	return stats, nil
+}
+
+// aggregated returns the records matched by the query, every record when it is nil
+func (ei *entityIndex) aggregated(q query.Expr) (idSet, error) {
+	records := idSet{}
+	if q == nil {
+		for _, id := range ei.ids {
+			records[id] = struct{}{}
+		}
+
+		return records, nil
+	}
+
+	set, err := ei.eval(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// relations can reference records that don't exist
+	for id := range set {
+		if _, ok := ei.order[id]; ok {
+			records[id] = struct{}{}
+		}
+	}
+
+	return records, nil
+}
+
+// countValues counts the records with every value, or with the values within every
+// interval when it is set. Returns the counts, the records that have any value and
+// the statistics of the numbers.
+func countValues(values map[string][]string, records idSet, interval string) (map[string]int, idSet, NumberStats) {
+	found := idSet{}
+	counts := map[string]int{}
+	var numbers NumberStats
+	for value, ids := range values {
+		n := 0
+		for _, id := range ids {
+			if _, ok := records[id]; ok {
+				found[id] = struct{}{}
+				n++
+			}
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		if number, kind, ok := query.ParseOrdered(value); ok && kind == query.Number {
+			numbers.add(number, n)
+		}
+
+		if interval == "" {
+			counts[value] += n
+			continue
+		}
+
+		if t, ok := query.ParseTime(value); ok {
+			counts[bucketKey(t, interval)] += n
+		}
+	}
+
+	return counts, found, numbers
+}
+
+// add counts n records with value
+func (ns *NumberStats) add(value float64, n int) {
+	if ns.Count == 0 {
+		ns.Min, ns.Max = value, value
+	}
+
+	ns.Count += n
+	ns.Sum += value * float64(n)
+	ns.Min = math.Min(ns.Min, value)
+	ns.Max = math.Max(ns.Max, value)
+}
+
+// valueIDs returns the values of the field and the IDs of the records of the entity
+// holding each of them. A field of a related entity, e.g. organization.name, returns
+// the values of the related records and the IDs of the records related to them.
+func (ei *entityIndex) valueIDs(field string) (map[string][]string, error) {
+	i := strings.Index(field, ".")
+	if i < 0 {
+		return ei.fields[field], nil
+	}
+
+	r, err := ei.relation(field[:i])
+	if err != nil {
+		return nil, err
+	}
+
+	related, err := r.target.valueIDs(field[i+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string, len(related))
+	for value, targetIDs := range related {
+		set := idSet{}
+		for _, id := range targetIDs {
+			set[id] = struct{}{}
+		}
+
+		for id := range r.relatedSet(set) {
+			values[value] = append(values[value], id)
+		}
+	}
+
+	return values, nil
+}
+
+// bucketKey returns the bucket of a date histogram t belongs to, in the time zone
+// it was written in. Weeks start on Monday and are named after it. Returns an empty
+// string for unknown intervals.
+func bucketKey(t time.Time, interval string) string {
+	switch interval {
+	case Day:
+		return t.Format("2006-01-02")
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset).Format("2006-01-02")
+	case Month:
+		return t.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+// sortBuckets sorts the buckets by count, most frequent first, and by value when
+// counts are equal. Dates are written so sorting them as strings is chronological.
+func sortBuckets(counts map[string]int) []Bucket {
+	buckets := make([]Bucket, 0, len(counts))
+	for value, count := range counts {
+		buckets = append(buckets, Bucket{Value: value, Count: count})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i], buckets[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+
+		return a.Value < b.Value
+	})
+
+	return buckets
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestStorage_Aggregate(t *testing.T) {
+	s := New(
+		model.Organizations{
+			{"_id": float64(101), "name": "Enthaze"},
+			{"_id": float64(102), "name": "Nutralab"},
+		},
+		nil,
+		model.Tickets{
+			{"_id": "a", "status": "open", "priority": "high", "organization_id": float64(101), "created_at": "2016-04-28T11:19:34 -10:00", "tags": []interface{}{"Ohio", "Texas"}},
+			{"_id": "b", "status": "open", "priority": "low", "organization_id": float64(101), "created_at": "2016-04-30T23:59:00 -10:00", "tags": []interface{}{"Ohio"}},
+			{"_id": "c", "status": "closed", "priority": "high", "organization_id": float64(102), "created_at": "2016-05-02T08:00:00 +10:00"},
+			{"_id": "d", "status": "pending"},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		entity   string
+		agg      Aggregation
+		expected Stats
+	}{
+		{
+			name:   "count_by_field",
+			entity: "tickets",
+			agg:    Aggregation{Field: "status"},
+			expected: Stats{
+				Entity: "tickets", Field: "status", Records: 4, Distinct: 3,
+				Buckets: []Bucket{{Value: "open", Count: 2}, {Value: "closed", Count: 1}, {Value: "pending", Count: 1}},
+			},
+		},
+		{
+			name:   "top_values_of_arrays",
+			entity: "tickets",
+			agg:    Aggregation{Field: "tags", Top: 1},
+			expected: Stats{
+				Entity: "tickets", Field: "tags", Records: 4, Missing: 2, Distinct: 2,
+				Buckets: []Bucket{{Value: "Ohio", Count: 2}},
+			},
+		},
+		{
+			name:   "filtered_by_query",
+			entity: "tickets",
+			agg:    Aggregation{Field: "priority", Query: query.Term{Field: "status", Value: "open"}},
+			expected: Stats{
+				Entity: "tickets", Field: "priority", Records: 2, Distinct: 2,
+				Buckets: []Bucket{{Value: "high", Count: 1}, {Value: "low", Count: 1}},
+			},
+		},
+		{
+			name:   "related_field",
+			entity: "tickets",
+			agg:    Aggregation{Field: "organization.name"},
+			expected: Stats{
+				Entity: "tickets", Field: "organization.name", Records: 4, Missing: 1, Distinct: 2,
+				Buckets: []Bucket{{Value: "Enthaze", Count: 2}, {Value: "Nutralab", Count: 1}},
+			},
+		},
+		{
+			name:   "numbers",
+			entity: "tickets",
+			agg:    Aggregation{Field: "organization_id", Top: 1},
+			expected: Stats{
+				Entity: "tickets", Field: "organization_id", Records: 4, Missing: 1, Distinct: 2,
+				Buckets: []Bucket{{Value: "101", Count: 2}},
+				Numbers: &NumberStats{Count: 3, Min: 101, Max: 102, Sum: 304, Avg: 304.0 / 3},
+			},
+		},
+		{
+			name:   "month_histogram",
+			entity: "tickets",
+			agg:    Aggregation{Field: "created_at", Interval: Month},
+			expected: Stats{
+				Entity: "tickets", Field: "created_at", Interval: Month, Records: 4, Missing: 1, Distinct: 2,
+				Buckets: []Bucket{{Value: "2016-04", Count: 2}, {Value: "2016-05", Count: 1}},
+			},
+		},
+		{
+			name:   "week_histogram_starts_on_monday",
+			entity: "tickets",
+			agg:    Aggregation{Field: "created_at", Interval: Week},
+			expected: Stats{
+				Entity: "tickets", Field: "created_at", Interval: Week, Records: 4, Missing: 1, Distinct: 2,
+				Buckets: []Bucket{{Value: "2016-04-25", Count: 2}, {Value: "2016-05-02", Count: 1}},
+			},
+		},
+		{
+			name:   "day_histogram_in_the_time_zone_of_the_value",
+			entity: "tickets",
+			agg:    Aggregation{Field: "created_at", Interval: Day},
+			expected: Stats{
+				Entity: "tickets", Field: "created_at", Interval: Day, Records: 4, Missing: 1, Distinct: 3,
+				Buckets: []Bucket{{Value: "2016-04-28", Count: 1}, {Value: "2016-04-30", Count: 1}, {Value: "2016-05-02", Count: 1}},
+			},
+		},
+		{
+			name:   "organizations_by_related_tickets",
+			entity: "organizations",
+			agg:    Aggregation{Field: "tickets.status"},
+			expected: Stats{
+				Entity: "organizations", Field: "tickets.status", Records: 2, Distinct: 2,
+				Buckets: []Bucket{{Value: "closed", Count: 1}, {Value: "open", Count: 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Aggregate(tt.entity, tt.agg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	_, err := s.Aggregate("tickets", Aggregation{Field: "created_at", Interval: "year"})
+	require.EqualError(t, err, `unknown interval "year", must be one of day, week, month`)
+
+	_, err = s.Aggregate("tickets", Aggregation{Field: "org.name"})
+	require.EqualError(t, err, `unknown relation "org", must be one of assignee, organization, submitter`)
+
+	_, err = s.Aggregate("groups", Aggregation{Field: "name"})
+	require.EqualError(t, err, "unknown entity: groups")
+}
+
+func TestStorage_Aggregate_TopIntervals(t *testing.T) {
+	s := New(nil, nil, model.Tickets{
+		{"_id": "a", "created_at": "2016-03-01T10:00:00 -10:00"},
+		{"_id": "b", "created_at": "2016-04-01T10:00:00 -10:00"},
+		{"_id": "c", "created_at": "2016-05-01T10:00:00 -10:00"},
+		{"_id": "d", "created_at": "2016-05-02T10:00:00 -10:00"},
+		{"_id": "e", "created_at": "2016-06-01T10:00:00 -10:00"},
+		{"_id": "f", "created_at": "2016-06-02T10:00:00 -10:00"},
+	})
+
+	got, err := s.Aggregate("tickets", Aggregation{Field: "created_at", Interval: Month, Top: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 4, got.Distinct)
+	assert.Equal(t, []Bucket{{Value: "2016-05", Count: 2}, {Value: "2016-06", Count: 2}}, got.Buckets)
+}
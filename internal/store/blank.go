@@ -0,0 +1,110 @@
+package store
+
+import "github.com/jaimem88/zearch/internal/query"
+
+// blankIndex keeps the records of an entity where a field is null or empty, and where
+// a field is present but has no values in the fieldIndex, e.g. null or []. Records
+// missing a field are the ones that are neither in the fieldIndex nor in unindexed,
+// so they don't need to be stored.
+type blankIndex struct {
+	nulls     map[string]idSet
+	empties   map[string]idSet
+	unindexed map[string]idSet
+}
+
+func newBlankIndex() *blankIndex {
+	return &blankIndex{
+		nulls:     map[string]idSet{},
+		empties:   map[string]idSet{},
+		unindexed: map[string]idSet{},
+	}
+}
+
+// add keeps track of the fields of the record that are null, empty or not indexed
+func (bi *blankIndex) add(id string, record map[string]interface{}) {
+	for field, v := range record {
+		if v == nil {
+			addToSet(bi.nulls, field, id)
+		}
+
+		if isEmpty(v) {
+			addToSet(bi.empties, field, id)
+		}
+
+		if len(indexValues(v)) == 0 {
+			addToSet(bi.unindexed, field, id)
+		}
+	}
+}
+
+// remove deletes the record from every set
+func (bi *blankIndex) remove(id string, record map[string]interface{}) {
+	for field := range record {
+		for _, sets := range []map[string]idSet{bi.nulls, bi.empties, bi.unindexed} {
+			delete(sets[field], id)
+			if len(sets[field]) == 0 {
+				delete(sets, field)
+			}
+		}
+	}
+}
+
+func addToSet(sets map[string]idSet, field, id string) {
+	set, ok := sets[field]
+	if !ok {
+		set = idSet{}
+		sets[field] = set
+	}
+
+	set[id] = struct{}{}
+}
+
+// isEmpty is true for empty strings and empty arrays
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// lookupIs returns the IDs of the records matching the predicate
+func (ei *entityIndex) lookupIs(is query.Is) (idSet, error) {
+	switch is.Predicate {
+	case query.IsNull:
+		return copySet(ei.blanks.nulls[is.Field]), nil
+	case query.IsEmpty:
+		return copySet(ei.blanks.empties[is.Field]), nil
+	case query.IsMissing:
+		present := copySet(ei.blanks.unindexed[is.Field])
+		for _, ids := range ei.fields[is.Field] {
+			for _, id := range ids {
+				present[id] = struct{}{}
+			}
+		}
+
+		set := idSet{}
+		for _, id := range ei.ids {
+			if _, ok := present[id]; !ok {
+				set[id] = struct{}{}
+			}
+		}
+
+		return set, nil
+	default:
+		return nil, invalidQuery("unknown predicate %s", is)
+	}
+}
+
+// copySet returns a copy of set so the result of a query can be modified
+func copySet(set idSet) idSet {
+	copied := make(idSet, len(set))
+	for id := range set {
+		copied[id] = struct{}{}
+	}
+
+	return copied
+}
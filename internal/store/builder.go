@@ -0,0 +1,112 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/reader"
+)
+
+// Builder creates a Storage by adding one record at a time, so records can be indexed
+// while they are being read instead of loading all of them in memory first.
+// Records of different entities can be added concurrently, but records of the same
+// entity must be added from a single goroutine.
+type Builder struct {
+	s *Storage
+}
+
+// NewBuilder creates a Builder for an empty Storage
+func NewBuilder() *Builder {
+	return &Builder{s: newStorage()}
+}
+
+// SetStemming enables or disables stemming in the full-text indexes, it is enabled
+// by default. It must be called before adding any record.
+func (b *Builder) SetStemming(stem bool) {
+	b.s.stem = stem
+	for _, e := range b.s.entities {
+		e.index.text.analyzer.Stem = stem
+	}
+}
+
+// SetEntities sets the entities defined in the entities config, the changes to
+// organizations, users and tickets, and the relations between all of them. It must be
+// called before adding any record. Returns an error describing the first entity or
+// relation that is not valid.
+func (b *Builder) SetEntities(configs []EntityConfig) error {
+	return b.s.setEntities(configs)
+}
+
+// Build returns the Storage with all the records added so far. The Builder must not
+// be used after calling Build.
+func (b *Builder) Build() *Storage {
+	return b.s
+}
+
+// AddOrganization stores and indexes an organization. Returns the model.FieldErrors
+// of the organization if it is not valid.
+func (b *Builder) AddOrganization(org model.Organization) error {
+	return b.Add("organizations", org)
+}
+
+// AddUser stores and indexes a user. Returns the model.FieldErrors of the user if it
+// is not valid.
+func (b *Builder) AddUser(user model.User) error {
+	return b.Add("users", user)
+}
+
+// AddTicket stores and indexes a ticket. Returns the model.FieldErrors of the ticket
+// if it is not valid.
+func (b *Builder) AddTicket(ticket model.Ticket) error {
+	return b.Add("tickets", ticket)
+}
+
+// Add stores and indexes a record of any entity. A duplicate ID replaces the previous
+// record, Check reports it. Records are related to the records of other entities by
+// the relations of the entities when they are searched, so they can be added in any
+// order. Returns the model.FieldErrors of the record if it is not valid.
+func (b *Builder) Add(entity string, record map[string]interface{}) error {
+	e, ok := b.s.entities[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity: %s", entity)
+	}
+
+	return e.add(record)
+}
+
+// CellTypes returns the types of the cells of the CSV files of the entity: the known
+// fields of organizations, users and tickets, its ID and the fields of its relations.
+func (b *Builder) CellTypes(entity string) reader.CellTypes {
+	cells := reader.CellTypes{}
+	if builtin, ok := findBuiltin(entity); ok {
+		for field, cellType := range builtin.cells {
+			cells[field] = cellType
+		}
+	}
+
+	e, ok := b.s.entities[entity]
+	if !ok {
+		return cells
+	}
+
+	delete(cells, e.config.IDField)
+	if e.config.IDType == model.IDNumber {
+		cells[e.config.IDField] = reader.CellNumber
+	}
+
+	for _, l := range b.s.links {
+		if l.source != entity {
+			continue
+		}
+
+		target := b.s.entities[l.target].config
+		switch {
+		case l.relation.Many:
+			cells[l.field] = reader.CellArray
+		case l.targetField == target.IDField && target.IDType == model.IDNumber:
+			cells[l.field] = reader.CellNumber
+		}
+	}
+
+	return cells
+}
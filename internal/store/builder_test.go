@@ -0,0 +1,39 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestBuilder_InvalidRecords(t *testing.T) {
+	b := NewBuilder()
+
+	var fieldErrs model.FieldErrors
+	require.ErrorAs(t, b.AddOrganization(model.Organization{"_id": "101"}), &fieldErrs)
+	require.ErrorAs(t, b.AddUser(model.User{"name": "Francis Bailey"}), &fieldErrs)
+	require.ErrorAs(t, b.AddTicket(model.Ticket{"_id": "1", "submitter_id": "1"}), &fieldErrs)
+	require.NoError(t, b.AddUser(model.User{"_id": float64(2), "name": "Cross Barlow"}))
+
+	s := b.Build()
+
+	_, err := s.Organizations(query.Term{Field: "_id", Value: "101"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = s.Tickets(query.Term{Field: "_id", Value: "1"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	users, err := s.Users(query.Term{Field: "name", Value: "Cross Barlow"})
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestNew_SkipsInvalidRecords(t *testing.T) {
+	require.NotPanics(t, func() {
+		New(model.Organizations{{"name": "no ID"}}, model.Users{{"_id": true}}, model.Tickets{{"_id": float64(1)}})
+	})
+}
@@ -0,0 +1,651 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/reader"
+)
+
+// EntitiesConfig is the content of the entities config file. It defines the entities
+// loaded along with organizations, users and tickets, e.g. the groups, brands and
+// satisfaction ratings of a Zendesk export.
+type EntitiesConfig struct {
+	Entities []EntityConfig `json:"entities"`
+}
+
+// EntityConfig defines an entity whose records can be any JSON object. An entry
+// named after organizations, users or tickets changes the fields it sets and adds its
+// relations to the built-in ones, their files are set with flags.
+type EntityConfig struct {
+	// Name is used in queries, commands and routes, e.g. groups
+	Name string `json:"name"`
+	// File is the data file with the records, relative to the working directory
+	File string `json:"file,omitempty"`
+	// IDField is the field that identifies every record, _id by default
+	IDField string `json:"id_field,omitempty"`
+	// IDType is the type of the IDs, model.IDNumber by default or model.IDString
+	IDType string `json:"id_type,omitempty"`
+	// Display are the fields printed in tables in order, all of them when it is empty
+	Display []string `json:"display,omitempty"`
+	// Text are the free-text fields added to the full-text index
+	Text      []string         `json:"text,omitempty"`
+	Relations []RelationConfig `json:"relations,omitempty"`
+}
+
+// RelationConfig relates the records of an entity to the records of another one whose
+// field has the same value, e.g. "organization_id -> organizations._id" relates the
+// records to the organization with their organization_id as _id. The field of the
+// target is its IDField when it is left out, e.g. "organization_id -> organizations".
+type RelationConfig struct {
+	// Name is used in joins like organization.name:Enthaze
+	Name string `json:"name"`
+	Link string `json:"link"`
+	// Inverse is the name of the relation in the other direction, e.g. groups from
+	// organizations, none when it is empty
+	Inverse string `json:"inverse,omitempty"`
+	// Show is a field of the related records included in the results as
+	// <name>_<show>, e.g. organization_name
+	Show string `json:"show,omitempty"`
+	// Many is true when the field has arrays of values, e.g. group_ids
+	Many bool `json:"many,omitempty"`
+}
+
+// builtin describes organizations, users and tickets. Their records are validated by
+// the model and their results are the model results, e.g. model.UserResult, unless the
+// config sets the fields to display.
+type builtin struct {
+	config   EntityConfig
+	text     []textField
+	cells    reader.CellTypes
+	validate func(record map[string]interface{}) error
+	result   func(s *Storage, key string, record map[string]interface{}, score float64) model.Result
+}
+
+// builtins are the entities every Storage has, the fields of their config can be changed
+// by an entry with the same name in the entities config, see Builder.SetEntities
+var builtins = []builtin{
+	{
+		config: EntityConfig{Name: "organizations", IDField: "_id", IDType: model.IDNumber},
+		text:   textFields["organizations"],
+		cells:  model.OrganizationCells,
+		validate: func(record map[string]interface{}) error {
+			_, err := model.Organization(record).Record()
+			return err
+		},
+		result: func(s *Storage, key string, record map[string]interface{}, score float64) model.Result {
+			return s.organizationResult(key, record, score)
+		},
+	},
+	{
+		config: EntityConfig{Name: "users", IDField: "_id", IDType: model.IDNumber, Relations: []RelationConfig{
+			{Name: "organization", Link: "organization_id -> organizations", Inverse: "users"},
+		}},
+		text:  textFields["users"],
+		cells: model.UserCells,
+		validate: func(record map[string]interface{}) error {
+			_, err := model.User(record).Record()
+			return err
+		},
+		result: func(s *Storage, key string, record map[string]interface{}, score float64) model.Result {
+			return s.userResult(key, record, score)
+		},
+	},
+	{
+		config: EntityConfig{Name: "tickets", IDField: "_id", IDType: model.IDString, Relations: []RelationConfig{
+			{Name: "organization", Link: "organization_id -> organizations", Inverse: "tickets"},
+			{Name: "submitter", Link: "submitter_id -> users", Inverse: "submitted_tickets"},
+			{Name: "assignee", Link: "assignee_id -> users", Inverse: "assigned_tickets"},
+		}},
+		text:  textFields["tickets"],
+		cells: model.TicketCells,
+		validate: func(record map[string]interface{}) error {
+			_, err := model.Ticket(record).Record()
+			return err
+		},
+		result: func(s *Storage, key string, record map[string]interface{}, score float64) model.Result {
+			return s.ticketResult(key, record, score)
+		},
+	},
+}
+
+// validName is the format of entity and relation names, they are used in URL paths
+// and joins so they can't have slashes or dots
+var validName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// reservedNames can't be used by entities, the server has routes with these names
+var reservedNames = []string{"fields", "schema"}
+
+// entity holds the records of an entity keyed by their ID. validate and result are
+// only set for the builtins.
+type entity struct {
+	config   EntityConfig
+	records  map[string]map[string]interface{}
+	index    *entityIndex
+	validate func(record map[string]interface{}) error
+	result   func(s *Storage, key string, record map[string]interface{}, score float64) model.Result
+}
+
+// link is a relation between the field of the records of source and the field of the
+// records of target with the same values
+type link struct {
+	relation    RelationConfig
+	source      string
+	field       string
+	target      string
+	targetField string
+}
+
+// parseLink parses the link of a relation of source, see RelationConfig. The field of
+// the target is empty when it is left out.
+func parseLink(source string, rc RelationConfig) (link, error) {
+	parts := strings.Split(rc.Link, "->")
+	if len(parts) != 2 {
+		return link{}, fmt.Errorf("link %q must be like organization_id -> organizations._id", rc.Link)
+	}
+
+	target := strings.SplitN(strings.TrimSpace(parts[1]), ".", 2)
+	l := link{
+		relation: rc,
+		source:   source,
+		field:    strings.TrimSpace(parts[0]),
+		target:   target[0],
+	}
+
+	if len(target) == 2 {
+		l.targetField = target[1]
+		if l.targetField == "" {
+			return link{}, fmt.Errorf("link %q must be like organization_id -> organizations._id", rc.Link)
+		}
+	}
+
+	if l.field == "" || l.target == "" {
+		return link{}, fmt.Errorf("link %q must be like organization_id -> organizations._id", rc.Link)
+	}
+
+	return l, nil
+}
+
+// findBuiltin returns the builtin named name, if any
+func findBuiltin(name string) (builtin, bool) {
+	for _, b := range builtins {
+		if b.config.Name == name {
+			return b, true
+		}
+	}
+
+	return builtin{}, false
+}
+
+// merge returns the config of the builtin with the fields set in configured, the
+// relations of configured are added to the built-in ones
+func (b builtin) merge(configured EntityConfig) (EntityConfig, error) {
+	config := b.config
+	if configured.File != "" {
+		return config, fmt.Errorf("is built-in, its file is set with -%s", config.Name)
+	}
+
+	if configured.IDField != "" {
+		config.IDField = configured.IDField
+	}
+
+	if configured.IDType != "" {
+		config.IDType = configured.IDType
+	}
+
+	config.Display = configured.Display
+	config.Text = configured.Text
+	config.Relations = append(append([]RelationConfig{}, b.config.Relations...), configured.Relations...)
+
+	return config, nil
+}
+
+// setEntities replaces the entities of a Storage without records with organizations,
+// users, tickets and the entities of the config, along with the relations of every entity
+func (s *Storage) setEntities(configs []EntityConfig) error {
+	s.entities = map[string]*entity{}
+	s.order = nil
+	s.links = nil
+
+	configured := map[string]EntityConfig{}
+	for _, config := range configs {
+		if _, ok := configured[config.Name]; ok {
+			return fmt.Errorf("entity %q: is defined more than once", config.Name)
+		}
+
+		configured[config.Name] = config
+	}
+
+	for _, b := range builtins {
+		config, err := b.merge(configured[b.config.Name])
+		if err == nil {
+			err = s.addEntity(config, b)
+		}
+
+		if err != nil {
+			return fmt.Errorf("entity %q: %w", config.Name, err)
+		}
+	}
+
+	for _, config := range configs {
+		if _, ok := findBuiltin(config.Name); ok {
+			continue
+		}
+
+		if err := s.addEntity(config, builtin{}); err != nil {
+			return fmt.Errorf("entity %q: %w", config.Name, err)
+		}
+	}
+
+	for _, name := range s.order {
+		for _, rc := range s.entities[name].config.Relations {
+			if err := s.addLink(name, rc); err != nil {
+				return fmt.Errorf("entity %q relation %q: %w", name, rc.Name, err)
+			}
+		}
+	}
+
+	s.configs = configs
+
+	return nil
+}
+
+// addEntity adds an entity without records, b is the zero builtin for the entities
+// of the config
+func (s *Storage) addEntity(config EntityConfig, b builtin) error {
+	if !validName.MatchString(config.Name) {
+		return fmt.Errorf("name must only have lowercase letters, digits, - and _")
+	}
+
+	for _, name := range reservedNames {
+		if config.Name == name {
+			return fmt.Errorf("name is reserved")
+		}
+	}
+
+	if config.File == "" && b.validate == nil {
+		return fmt.Errorf("file is required")
+	}
+
+	if config.IDField == "" {
+		config.IDField = "_id"
+	}
+
+	switch config.IDType {
+	case "":
+		config.IDType = model.IDNumber
+	case model.IDNumber, model.IDString:
+	default:
+		return fmt.Errorf("id_type must be %s or %s", model.IDNumber, model.IDString)
+	}
+
+	// the built-in text fields rank matches in some fields higher
+	fields := b.text
+	if len(config.Text) > 0 {
+		fields = make([]textField, 0, len(config.Text))
+		for _, name := range config.Text {
+			fields = append(fields, textField{name: name, boost: 1})
+		}
+	}
+
+	config.Text = nil
+	for _, field := range fields {
+		config.Text = append(config.Text, field.name)
+	}
+
+	ei := newEntityIndex(fields)
+	ei.text.analyzer.Stem = s.stem
+	ei.joins = map[string]relation{}
+
+	validate := b.validate
+	if validate != nil && (config.IDField != b.config.IDField || config.IDType != b.config.IDType) {
+		// the model requires the built-in ID, it is checked like the IDs of the config
+		validate = ignoreField(validate, b.config.IDField)
+	}
+
+	s.entities[config.Name] = &entity{
+		config:   config,
+		records:  map[string]map[string]interface{}{},
+		index:    ei,
+		validate: validate,
+		result:   b.result,
+	}
+	s.order = append(s.order, config.Name)
+
+	return nil
+}
+
+// ignoreField returns a validate function that doesn't report the errors of field
+func ignoreField(validate func(record map[string]interface{}) error, field string) func(record map[string]interface{}) error {
+	return func(record map[string]interface{}) error {
+		var errs model.FieldErrors
+		if !errors.As(validate(record), &errs) {
+			return nil
+		}
+
+		var kept model.FieldErrors
+		for _, err := range errs {
+			if err.Field != field {
+				kept = append(kept, err)
+			}
+		}
+
+		if len(kept) == 0 {
+			return nil
+		}
+
+		return kept
+	}
+}
+
+// addLink validates a relation of source and adds it and its inverse to the joins
+func (s *Storage) addLink(source string, rc RelationConfig) error {
+	if !validName.MatchString(rc.Name) {
+		return fmt.Errorf("name must only have lowercase letters, digits, - and _")
+	}
+
+	l, err := parseLink(source, rc)
+	if err != nil {
+		return err
+	}
+
+	sourceEntity := s.entities[source]
+	targetEntity, ok := s.entities[l.target]
+	if !ok {
+		return fmt.Errorf("unknown entity %q", l.target)
+	}
+
+	if l.targetField == "" {
+		l.targetField = targetEntity.config.IDField
+	}
+
+	sourceIndex, targetIndex := sourceEntity.index, targetEntity.index
+	if _, ok := sourceIndex.joins[rc.Name]; ok {
+		return fmt.Errorf("%s already has a relation with this name", source)
+	}
+
+	if rc.Show != "" && sourceEntity.result != nil && len(sourceEntity.config.Display) == 0 {
+		return fmt.Errorf("show needs the display fields of %s to be set", source)
+	}
+
+	if rc.Inverse != "" {
+		if !validName.MatchString(rc.Inverse) {
+			return fmt.Errorf("inverse must only have lowercase letters, digits, - and _")
+		}
+
+		if _, ok := targetIndex.joins[rc.Inverse]; ok {
+			return fmt.Errorf("%s already has a relation named %q", l.target, rc.Inverse)
+		}
+	}
+
+	sourceIndex.joins[rc.Name] = joinTo(targetIndex, func(id string) []string {
+		return s.linking(l, id)
+	})
+
+	if rc.Inverse != "" {
+		targetIndex.joins[rc.Inverse] = joinTo(sourceIndex, func(id string) []string {
+			return s.linked(l, id)
+		})
+	}
+
+	s.links = append(s.links, l)
+
+	return nil
+}
+
+// linked returns the keys of the records of the target of l related to the record of
+// the source with the key
+func (s *Storage) linked(l link, key string) []string {
+	record, _ := s.Record(l.source, key)
+	targetIndex, _ := s.index(l.target)
+
+	return relatedKeys(record[l.field], targetIndex, l.targetField)
+}
+
+// linking returns the keys of the records of the source of l related to the record of
+// the target with the key
+func (s *Storage) linking(l link, key string) []string {
+	record, _ := s.Record(l.target, key)
+	sourceIndex, _ := s.index(l.source)
+
+	return relatedKeys(record[l.targetField], sourceIndex, l.field)
+}
+
+// relatedKeys returns the keys of the records of ei that have any of the values of v
+// in field, without repeating them
+func relatedKeys(v interface{}, ei *entityIndex, field string) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, value := range indexValues(v) {
+		for _, key := range ei.fields.lookup(field, value) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// related returns the entity and the keys of the records related to the record of
+// entityName with the key by the relation, or the inverse of a relation, named name
+func (s *Storage) related(entityName, name, key string) (string, []string) {
+	for _, l := range s.links {
+		switch {
+		case l.source == entityName && l.relation.Name == name:
+			return l.target, s.linked(l, key)
+		case l.target == entityName && l.relation.Inverse == name:
+			return l.source, s.linking(l, key)
+		}
+	}
+
+	return "", nil
+}
+
+// relatedStrings returns the field of the records related to the record of entityName
+// with the key by the relation named name, see related. Values that are not strings
+// are empty.
+func (s *Storage) relatedStrings(entityName, name, key, field string) []string {
+	target, keys := s.related(entityName, name, key)
+
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		record, _ := s.Record(target, k)
+		value, _ := record[field].(string)
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// relatedString returns the field of the first record related to the record of
+// entityName with the key, see relatedStrings
+func (s *Storage) relatedString(entityName, name, key, field string) string {
+	values := s.relatedStrings(entityName, name, key, field)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Entities describes every entity of the Storage, organizations, users and tickets
+// first and then the entities of the config in the order they were defined
+func (s *Storage) Entities() []EntityConfig {
+	configs := make([]EntityConfig, 0, len(s.order))
+	for _, name := range s.order {
+		configs = append(configs, s.entities[name].config)
+	}
+
+	return configs
+}
+
+// Config returns the entities config the Storage was created with, see Builder.SetEntities
+func (s *Storage) Config() []EntityConfig {
+	return s.configs
+}
+
+// Search evaluates the query against the index of any entity. The results of
+// organizations, users and tickets are model.OrganizationResult, model.UserResult and
+// model.TicketResult unless the config sets their display fields, the results of the
+// other entities are model.EntityResult.
+func (s *Storage) Search(entityName string, q query.Expr) ([]model.Result, error) {
+	e, ok := s.entities[entityName]
+	if !ok {
+		return nil, invalidQuery("unknown entity: %s", entityName)
+	}
+
+	var results []model.Result
+	err := s.find(e, q, func(key string, record map[string]interface{}, score float64) {
+		results = append(results, s.result(e, key, record, score))
+	})
+
+	return results, err
+}
+
+// find evaluates the query against the index of the entity and calls found with every
+// record matched, in the order of the results. Returns ErrNotFound when there are none.
+func (s *Storage) find(e *entity, q query.Expr, found func(key string, record map[string]interface{}, score float64)) error {
+	keys, scores, err := e.index.search(q)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, key := range keys {
+		record, ok := e.records[key]
+		if !ok {
+			continue
+		}
+
+		found(key, record, scores[key])
+		n++
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// result returns the result of the record of the entity with the key. The built-in
+// results have the names of their related records, which are kept when their display
+// fields are set.
+func (s *Storage) result(e *entity, key string, record map[string]interface{}, score float64) model.Result {
+	if e.result != nil && len(e.config.Display) == 0 {
+		return e.result(s, key, record, score)
+	}
+
+	var relations []model.Field
+	if e.result != nil {
+		relations = e.result(s, key, record, 0).Related()
+	}
+
+	return model.EntityResult{
+		Record:    record,
+		Display:   e.config.Display,
+		Relations: append(relations, s.shownFields(e.config.Name, key)...),
+		Score:     score,
+	}
+}
+
+// shownFields returns the fields of the related records that are shown in the results
+// of the record of the entity with the key, see RelationConfig.Show. A relation that
+// isn't Many and links to the ID of the target relates to a single record so its field
+// is a string, otherwise the fields of all the related records are listed. The type only
+// depends on the relation so every record has the same one.
+func (s *Storage) shownFields(entityName, key string) []model.Field {
+	var fields []model.Field
+	for _, l := range s.links {
+		if l.source != entityName || l.relation.Show == "" {
+			continue
+		}
+
+		values := []string{}
+		for _, targetKey := range s.linked(l, key) {
+			target, _ := s.Record(l.target, targetKey)
+			values = append(values, indexValues(target[l.relation.Show])...)
+		}
+
+		name := l.relation.Name + "_" + l.relation.Show
+		if l.relation.Many || l.targetField != s.idField(l.target) {
+			fields = append(fields, model.Field{Name: name, Value: values})
+			continue
+		}
+
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+
+		fields = append(fields, model.Field{Name: name, Value: value})
+	}
+
+	return fields
+}
+
+// idField returns the field that identifies the records of the entity
+func (s *Storage) idField(entityName string) string {
+	if e, ok := s.entities[entityName]; ok {
+		return e.config.IDField
+	}
+
+	return "_id"
+}
+
+// add stores and indexes a record, a duplicate ID replaces the previous record
+func (e *entity) add(record map[string]interface{}) error {
+	key, err := e.recordKey(record)
+	if err != nil {
+		return err
+	}
+
+	if old, ok := e.records[key]; ok {
+		e.index.remove(key, old)
+	}
+
+	e.records[key] = record
+	e.index.add(key, record)
+
+	return nil
+}
+
+// recordKey validates the record and returns the key it is stored under. Returns the
+// model.FieldErrors of the record if it is not valid.
+func (e *entity) recordKey(record map[string]interface{}) (string, error) {
+	if e.validate != nil {
+		if err := e.validate(record); err != nil {
+			return "", err
+		}
+	}
+
+	return model.RecordID(record, e.config.IDField, e.config.IDType)
+}
+
+// key returns the key the record with the ID is stored under, numeric IDs are
+// written like model.RecordID does, so e.g. 1.0 finds the record 1
+func (e *entity) key(id string) string {
+	if e.config.IDType == model.IDString {
+		return id
+	}
+
+	n, err := strconv.ParseFloat(id, 64)
+	if err != nil {
+		return id
+	}
+
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// Field returns the field of the records that relates them to the target of the link,
+// e.g. organization_id
+func (rc RelationConfig) Field() string {
+	return strings.TrimSpace(strings.SplitN(rc.Link, "->", 2)[0])
+}
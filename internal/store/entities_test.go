@@ -0,0 +1,392 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/reader"
+)
+
+var testEntities = []EntityConfig{
+	{
+		Name:    "groups",
+		File:    "groups.json",
+		IDField: "id",
+		Display: []string{"id", "name"},
+		Text:    []string{"name"},
+		Relations: []RelationConfig{
+			{Name: "organization", Link: "organization_id -> organizations._id", Inverse: "groups", Show: "name"},
+		},
+	},
+	{
+		Name:    "brands",
+		File:    "brands.json",
+		IDField: "subdomain",
+		IDType:  model.IDString,
+		Relations: []RelationConfig{
+			{Name: "groups", Link: "group_ids -> groups.id", Inverse: "brands", Show: "name", Many: true},
+		},
+	},
+	{
+		Name: "tickets",
+		Relations: []RelationConfig{
+			{Name: "group", Link: "group_id -> groups.id", Inverse: "tickets"},
+		},
+	},
+}
+
+// newEntitiesStorage creates a Storage with the testEntities
+func newEntitiesStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities(testEntities))
+
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze"}))
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(102), "name": "Nutralab"}))
+	require.NoError(t, b.Add("groups", map[string]interface{}{"id": float64(1), "name": "Support Agents", "organization_id": float64(101)}))
+	require.NoError(t, b.Add("groups", map[string]interface{}{"id": float64(2), "name": "Billing", "organization_id": float64(102)}))
+	require.NoError(t, b.Add("groups", map[string]interface{}{"id": float64(3), "name": "Sales", "organization_id": float64(999)}))
+	require.NoError(t, b.Add("brands", map[string]interface{}{"subdomain": "kage", "group_ids": []interface{}{float64(1), float64(2)}}))
+	require.NoError(t, b.Add("brands", map[string]interface{}{"subdomain": "zolar", "group_ids": []interface{}{float64(3), float64(4)}}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "subject": "A Catastrophe in Korea", "group_id": float64(1)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "b", "subject": "A Drama in Portugal", "group_id": float64(2)}))
+
+	return b.Build()
+}
+
+func TestBuilder_SetEntities(t *testing.T) {
+	tests := []struct {
+		name          string
+		configs       []EntityConfig
+		expectedError string
+	}{
+		{
+			name:    "valid",
+			configs: testEntities,
+		},
+		{
+			name:          "invalid_name",
+			configs:       []EntityConfig{{Name: "Groups", File: "groups.json"}},
+			expectedError: `entity "Groups": name must only have lowercase letters, digits, - and _`,
+		},
+		{
+			name:          "reserved_name",
+			configs:       []EntityConfig{{Name: "fields", File: "fields.json"}},
+			expectedError: `entity "fields": name is reserved`,
+		},
+		{
+			name:          "missing_file",
+			configs:       []EntityConfig{{Name: "groups"}},
+			expectedError: `entity "groups": file is required`,
+		},
+		{
+			name:          "defined_twice",
+			configs:       []EntityConfig{{Name: "groups", File: "groups.json"}, {Name: "groups", File: "groups.json"}},
+			expectedError: `entity "groups": is defined more than once`,
+		},
+		{
+			name:          "invalid_id_type",
+			configs:       []EntityConfig{{Name: "groups", File: "groups.json", IDType: "uuid"}},
+			expectedError: `entity "groups": id_type must be number or string`,
+		},
+		{
+			name:          "builtin_fields",
+			configs:       []EntityConfig{{Name: "users", File: "users.json"}},
+			expectedError: `entity "users": is built-in, its file is set with -users`,
+		},
+		{
+			name: "invalid_link",
+			configs: []EntityConfig{{Name: "groups", File: "groups.json", Relations: []RelationConfig{
+				{Name: "organization", Link: "organization_id organizations._id"},
+			}}},
+			expectedError: `entity "groups" relation "organization": link "organization_id organizations._id" must be like organization_id -> organizations._id`,
+		},
+		{
+			name: "unknown_target",
+			configs: []EntityConfig{{Name: "groups", File: "groups.json", Relations: []RelationConfig{
+				{Name: "brand", Link: "brand_id -> brands.id"},
+			}}},
+			expectedError: `entity "groups" relation "brand": unknown entity "brands"`,
+		},
+		{
+			name: "relation_exists",
+			configs: []EntityConfig{{Name: "tickets", Relations: []RelationConfig{
+				{Name: "organization", Link: "organization_id -> organizations._id"},
+			}}},
+			expectedError: `entity "tickets" relation "organization": tickets already has a relation with this name`,
+		},
+		{
+			name: "inverse_exists",
+			configs: []EntityConfig{{Name: "groups", File: "groups.json", Relations: []RelationConfig{
+				{Name: "organization", Link: "organization_id -> organizations._id", Inverse: "users"},
+			}}},
+			expectedError: `entity "groups" relation "organization": organizations already has a relation named "users"`,
+		},
+		{
+			name: "show_builtin",
+			configs: []EntityConfig{
+				{Name: "groups", File: "groups.json"},
+				{Name: "tickets", Relations: []RelationConfig{{Name: "group", Link: "group_id -> groups._id", Show: "name"}}},
+			},
+			expectedError: `entity "tickets" relation "group": show needs the display fields of tickets to be set`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewBuilder().SetEntities(tt.configs)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBuilder_Add(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities(testEntities))
+
+	err := b.Add("brands", map[string]interface{}{"subdomain": float64(1)})
+	require.EqualError(t, err, `field "subdomain": expected a string but got number 1`)
+
+	var fieldErrs model.FieldErrors
+	assert.ErrorAs(t, err, &fieldErrs)
+
+	require.EqualError(t, b.Add("macros", map[string]interface{}{}), "unknown entity: macros")
+	require.NoError(t, b.Add("users", map[string]interface{}{"_id": float64(1)}))
+}
+
+func TestStorage_Search_Entities(t *testing.T) {
+	s := newEntitiesStorage(t)
+
+	tests := []struct {
+		name     string
+		entity   string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "by_field",
+			entity:   "groups",
+			query:    "name:Billing",
+			expected: []string{"2"},
+		},
+		{
+			name:     "by_text",
+			entity:   "groups",
+			query:    "text:agent",
+			expected: []string{"1"},
+		},
+		{
+			name:     "by_relation",
+			entity:   "groups",
+			query:    "organization.name:Enthaze",
+			expected: []string{"1"},
+		},
+		{
+			name:     "by_inverse_relation",
+			entity:   "organizations",
+			query:    "groups.name:Billing",
+			expected: []string{"102"},
+		},
+		{
+			name:     "string_ids_by_array_relation",
+			entity:   "brands",
+			query:    "groups.organization.name:Nutralab",
+			expected: []string{"kage"},
+		},
+		{
+			name:     "builtin_by_relation",
+			entity:   "tickets",
+			query:    "group.organization.name:Enthaze",
+			expected: []string{"a"},
+		},
+		{
+			name:     "by_inverse_relation_of_builtin",
+			entity:   "groups",
+			query:    "tickets._id:b AND brands.subdomain:kage",
+			expected: []string{"2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			results, err := s.Search(tt.entity, q)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, result := range results {
+				id, _ := formatValue(result.Fields()[s.idField(tt.entity)])
+				ids = append(ids, id)
+			}
+
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	_, err := s.Search("groups", query.Term{Field: "name", Value: "Marketing"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = s.Search("macros", query.Term{Field: "name", Value: "Marketing"})
+	assert.EqualError(t, err, "unknown entity: macros")
+}
+
+func TestStorage_Search_ShownFields(t *testing.T) {
+	s := newEntitiesStorage(t)
+
+	results, err := s.Search("groups", query.Term{Field: "id", Value: "3"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Result{model.EntityResult{
+		Record:    map[string]interface{}{"id": float64(3), "name": "Sales", "organization_id": float64(999)},
+		Display:   []string{"id", "name"},
+		Relations: []model.Field{{Name: "organization_name", Value: ""}},
+	}}, results)
+
+	results, err = s.Search("brands", query.Term{Field: "subdomain", Value: "kage"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Field{{Name: "groups_name", Value: []string{"Support Agents", "Billing"}}}, results[0].Related())
+
+	// the type of the field depends on the relation, not on the record
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities(testEntities))
+	require.NoError(t, b.Add("brands", map[string]interface{}{"subdomain": "kage"}))
+	results, err = b.Build().Search("brands", query.Term{Field: "subdomain", Value: "kage"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Field{{Name: "groups_name", Value: []string{}}}, results[0].Related())
+}
+
+func TestStorage_Check_Entities(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities(testEntities))
+	require.NoError(t, b.Add("groups", map[string]interface{}{"id": float64(1), "organization_id": float64(999)}))
+	require.NoError(t, b.Add("groups", map[string]interface{}{"id": float64(1)}))
+	require.NoError(t, b.Add("brands", map[string]interface{}{"subdomain": "kage", "group_ids": []interface{}{float64(1), float64(2)}}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "group_id": float64(3)}))
+	s := b.Build()
+
+	report := s.Check()
+	assert.Equal(t, map[string]int{"organizations": 0, "users": 0, "tickets": 1, "groups": 1, "brands": 1}, report.Records)
+	assert.Equal(t, []Duplicate{{Entity: "groups", ID: "1", Count: 2}}, report.Duplicates)
+	assert.Equal(t, []DanglingReference{
+		{Entity: "tickets", ID: "a", Field: "group_id", Target: "groups", Value: "3"},
+		{Entity: "brands", ID: "kage", Field: "group_ids", Target: "groups", Value: "2"},
+	}, report.DanglingReferences)
+}
+
+func TestStorage_Mutate_Entities(t *testing.T) {
+	s := newEntitiesStorage(t)
+
+	err := s.Insert("groups", map[string]interface{}{"id": float64(2)})
+	assert.EqualError(t, err, "groups 2: already exists")
+
+	require.NoError(t, s.Insert("groups", map[string]interface{}{"id": float64(4), "name": "Marketing", "organization_id": float64(102)}))
+	require.NoError(t, s.Update("groups", map[string]interface{}{"id": float64(1), "name": "Escalations", "organization_id": float64(102)}))
+	require.NoError(t, s.Delete("brands", "zolar"))
+
+	record, ok := s.Record("groups", "1.0")
+	require.True(t, ok)
+	assert.Equal(t, "Escalations", record["name"])
+
+	_, ok = s.Record("brands", "zolar")
+	assert.False(t, ok)
+	assert.EqualError(t, s.Delete("brands", "zolar"), "brands zolar: not found")
+
+	records, err := s.Records("groups")
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "Escalations", records[0]["name"])
+	assert.Equal(t, "Marketing", records[3]["name"])
+
+	results, err := s.Search("organizations", query.Join{Relation: "groups", Expr: query.Term{Field: "name", Value: "Marketing"}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, float64(102), results[0].Fields()["_id"])
+
+	_, err = s.Search("groups", query.Term{Field: "name", Value: "Support Agents"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStorage_Entities(t *testing.T) {
+	s := newEntitiesStorage(t)
+
+	var names []string
+	for _, config := range s.Entities() {
+		names = append(names, config.Name)
+	}
+
+	assert.Equal(t, []string{"organizations", "users", "tickets", "groups", "brands"}, names)
+
+	tickets := s.Entities()[2]
+	assert.Equal(t, "_id", tickets.IDField)
+	assert.Equal(t, model.IDString, tickets.IDType)
+	assert.Equal(t, []string{"subject", "description"}, tickets.Text)
+	assert.Equal(t, append(builtins[2].config.Relations, testEntities[2].Relations...), tickets.Relations)
+
+	groups := s.Entities()[3]
+	assert.Equal(t, "id", groups.IDField)
+	assert.Equal(t, "id", groups.Display[0])
+	assert.Equal(t, model.IDNumber, groups.IDType)
+
+	assert.Contains(t, s.GetSearchableFields(), "brands")
+	assert.Contains(t, s.Schema(), "groups")
+}
+
+func TestStorage_Search_BuiltinConfig(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities([]EntityConfig{{
+		Name:    "users",
+		IDField: "email",
+		IDType:  model.IDString,
+		Display: []string{"email", "name"},
+		Text:    []string{"signature"},
+	}}))
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze"}))
+	require.NoError(t, b.AddUser(model.User{"email": "francis@example.com", "name": "Francis Bailey", "signature": "Don't Worry Be Happy!", "organization_id": float64(101)}))
+	s := b.Build()
+
+	results, err := s.Search("users", query.Text{Value: "happy"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	result, ok := results[0].(model.EntityResult)
+	require.True(t, ok)
+	assert.Equal(t, []string{"email", "name"}, result.Display)
+	assert.Equal(t, model.Field{Name: "organization_name", Value: "Enthaze"}, result.Relations[0])
+
+	_, ok = s.Record("users", "francis@example.com")
+	assert.True(t, ok)
+
+	results, err = s.Search("organizations", query.Join{Relation: "users", Expr: query.Term{Field: "email", Value: "francis@example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, float64(101), results[0].Fields()["_id"])
+}
+
+func TestBuilder_CellTypes(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.SetEntities(testEntities))
+
+	assert.Equal(t, reader.CellTypes{
+		"_id":             reader.CellNumber,
+		"active":          reader.CellBoolean,
+		"verified":        reader.CellBoolean,
+		"shared":          reader.CellBoolean,
+		"organization_id": reader.CellNumber,
+		"tags":            reader.CellArray,
+		"suspended":       reader.CellBoolean,
+	}, b.CellTypes("users"))
+	assert.Equal(t, reader.CellTypes{"id": reader.CellNumber, "organization_id": reader.CellNumber}, b.CellTypes("groups"))
+	assert.Equal(t, reader.CellTypes{"group_ids": reader.CellArray}, b.CellTypes("brands"))
+	assert.Equal(t, reader.CellNumber, b.CellTypes("tickets")["group_id"])
+	assert.Empty(t, b.CellTypes("macros"))
+}
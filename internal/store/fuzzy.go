@@ -0,0 +1,219 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/text"
+)
+
+// fuzzyField is a trigram index of the distinct values of a field. Only the keys
+// sharing enough trigrams with the search value are compared with it, instead of
+// every value of the field, see text.Trigrams.
+type fuzzyField struct {
+	// keys maps the fuzzy keys of the values to the values they come from,
+	// see text.FuzzyKeys
+	keys map[string][]string
+	// grams maps every trigram to the keys containing it
+	grams map[string][]string
+}
+
+func newFuzzyField(values map[string][]string) *fuzzyField {
+	ff := &fuzzyField{
+		keys:  map[string][]string{},
+		grams: map[string][]string{},
+	}
+
+	for value := range values {
+		for _, key := range text.FuzzyKeys(value) {
+			if _, ok := ff.keys[key]; !ok {
+				for _, gram := range text.Trigrams(key) {
+					ff.grams[gram] = append(ff.grams[gram], key)
+				}
+			}
+
+			ff.keys[key] = append(ff.keys[key], value)
+		}
+	}
+
+	return ff
+}
+
+// match returns the values with a key within text.MaxDistance of value and their
+// distance to it
+func (ff *fuzzyField) match(value string) map[string]int {
+	value = strings.ToLower(value)
+	max := text.MaxDistance(value)
+
+	grams := text.Trigrams(value)
+	// every edit changes at most 4 trigrams so keys within max share at least this many,
+	// short values share too few to filter anything and every key is compared
+	minShared := len(grams) - 4*max
+
+	var candidates []string
+	if minShared > 0 {
+		shared := map[string]int{}
+		for _, gram := range grams {
+			for _, key := range ff.grams[gram] {
+				shared[key]++
+			}
+		}
+
+		for key, n := range shared {
+			if n >= minShared {
+				candidates = append(candidates, key)
+			}
+		}
+	} else {
+		for key := range ff.keys {
+			candidates = append(candidates, key)
+		}
+	}
+
+	matches := map[string]int{}
+	for _, key := range candidates {
+		d := text.Distance(value, key, max)
+		if d > max {
+			continue
+		}
+
+		for _, v := range ff.keys[key] {
+			if current, ok := matches[v]; !ok || d < current {
+				matches[v] = d
+			}
+		}
+	}
+
+	return matches
+}
+
+// fuzzyIndex keeps the trigram index of the fields used in fuzzy queries. Like
+// rangeIndex, fields are indexed the first time they are queried and again after the
+// records change.
+type fuzzyIndex struct {
+	m      sync.Mutex
+	fields map[string]*fuzzyField
+}
+
+// field returns the trigram index of field, building it if needed
+func (fi *fuzzyIndex) field(field string, values map[string][]string) *fuzzyField {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	if ff, ok := fi.fields[field]; ok {
+		return ff
+	}
+
+	if fi.fields == nil {
+		fi.fields = map[string]*fuzzyField{}
+	}
+
+	ff := newFuzzyField(values)
+	fi.fields[field] = ff
+
+	return ff
+}
+
+// reset discards the trigram indexes after the records change
+func (fi *fuzzyIndex) reset() {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	fi.fields = nil
+}
+
+// lookupFuzzy returns the IDs of the records with a value of the field within a
+// few typos of the value of the term, along with the smallest distance of each
+func (ei *entityIndex) lookupFuzzy(t query.Term) map[string]int {
+	distances := map[string]int{}
+	for value, d := range ei.fuzzy.field(t.Field, ei.fields[t.Field]).match(t.Value) {
+		for _, id := range ei.fields.lookup(t.Field, value) {
+			if current, ok := distances[id]; !ok || d < current {
+				distances[id] = d
+			}
+		}
+	}
+
+	return distances
+}
+
+// fuzzyScores scores the records found by the fuzzy terms of expr by their edit
+// distance, an exact match scores 1 and every typo lowers the score. Terms inside a
+// NOT are ignored, like in textIndex.rankedTokens.
+func (ei *entityIndex) fuzzyScores(expr query.Expr, ids []string) map[string]float64 {
+	terms := fuzzyTerms(expr)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	scores := map[string]float64{}
+	for _, t := range terms {
+		distances := ei.lookupFuzzy(t)
+		for _, id := range ids {
+			if d, ok := distances[id]; ok {
+				scores[id] += 1 / float64(1+d)
+			}
+		}
+	}
+
+	return scores
+}
+
+func fuzzyTerms(expr query.Expr) []query.Term {
+	switch e := expr.(type) {
+	case query.Term:
+		if e.Mode == query.Fuzzy {
+			return []query.Term{e}
+		}
+
+		return nil
+	case query.And:
+		return append(fuzzyTerms(e.Left), fuzzyTerms(e.Right)...)
+	case query.Or:
+		return append(fuzzyTerms(e.Left), fuzzyTerms(e.Right)...)
+	default:
+		return nil
+	}
+}
+
+// suggest returns up to limit distinct values of the field within a few typos of
+// value, closest first
+func (ei *entityIndex) suggest(field, value string, limit int) []string {
+	matches := ei.fuzzy.field(field, ei.fields[field]).match(value)
+
+	suggestions := make([]string, 0, len(matches))
+	for v := range matches {
+		if v != value {
+			suggestions = append(suggestions, v)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if matches[a] != matches[b] {
+			return matches[a] < matches[b]
+		}
+
+		return a < b
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions
+}
+
+// Suggest returns up to 3 values of the field of the entity that are within a few
+// typos of value, closest first. It is used to suggest what the user meant when a
+// search doesn't find anything.
+func (s *Storage) Suggest(entity, field, value string) []string {
+	ei, ok := s.index(entity)
+	if !ok {
+		return nil
+	}
+
+	return ei.suggest(field, value, 3)
+}
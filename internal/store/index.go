@@ -0,0 +1,129 @@
+package store
+
+import "strconv"
+
+// fieldIndex is an inverted index for a single entity. It maps every field to the
+// values found in that field and the IDs of the records holding each value, so
+// searching by any term is a map lookup instead of a scan over all the records.
+// IDs are stored as strings so the same index works for organizations, users and tickets.
+type fieldIndex map[string]map[string][]string
+
+// add indexes every field of the record under id. Array fields like tags and
+// domain_names are indexed per element.
+func (fi fieldIndex) add(id string, record map[string]interface{}) {
+	for field, v := range record {
+		values, ok := fi[field]
+		if !ok {
+			values = map[string][]string{}
+			fi[field] = values
+		}
+
+		for _, value := range indexValues(v) {
+			ids := values[value]
+			// the same value can appear more than once in an array, records are added
+			// one at a time so a duplicate would be the last ID in the list
+			if len(ids) > 0 && ids[len(ids)-1] == id {
+				continue
+			}
+
+			values[value] = append(ids, id)
+		}
+	}
+}
+
+// remove deletes id from the values of every field of the record. It is used when a
+// record is replaced so its old values don't match anymore.
+func (fi fieldIndex) remove(id string, record map[string]interface{}) {
+	for field, v := range record {
+		values, ok := fi[field]
+		if !ok {
+			continue
+		}
+
+		for _, value := range indexValues(v) {
+			ids := removeKey(values[value], id)
+			if len(ids) == 0 {
+				delete(values, value)
+				continue
+			}
+
+			values[value] = ids
+		}
+
+		if len(values) == 0 {
+			delete(fi, field)
+		}
+	}
+}
+
+// removeKey returns keys without key, keeping the order of the rest
+func removeKey(keys []string, key string) []string {
+	for k, existing := range keys {
+		if existing == key {
+			return append(keys[:k:k], keys[k+1:]...)
+		}
+	}
+
+	return keys
+}
+
+// lookup returns the IDs of the records that have value in field
+func (fi fieldIndex) lookup(field, value string) []string {
+	return fi[field][value]
+}
+
+// match returns the IDs of the records that have a value in field for which matches
+// returns true. It scans the distinct values of the field instead of the records.
+func (fi fieldIndex) match(field string, matches func(string) bool) idSet {
+	set := idSet{}
+	for value, ids := range fi[field] {
+		if !matches(value) {
+			continue
+		}
+
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// indexValues returns the keys v should be indexed by
+func indexValues(v interface{}) []string {
+	elems, ok := v.([]interface{})
+	if !ok {
+		elems = []interface{}{v}
+	}
+
+	values := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		value, ok := formatValue(elem)
+		if !ok {
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// formatValue converts a JSON scalar into the string representation used by the
+// index and by the search values typed by the user. Null values and objects are
+// not indexed.
+func formatValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		// formats whole numbers without decimals e.g. 101 instead of 101.000000
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldIndex(t *testing.T) {
+	fi := fieldIndex{}
+	fi.add("1", map[string]interface{}{
+		"_id":    float64(1),
+		"name":   "Francis Bailey",
+		"active": true,
+		"tags":   []interface{}{"Leola", "Veguita", "Leola"},
+		"alias":  nil,
+		"logo":   map[string]interface{}{"url": "logo.png"},
+	})
+	fi.add("2", map[string]interface{}{
+		"_id":    float64(2),
+		"name":   "Cross Barlow",
+		"active": true,
+		"tags":   []interface{}{"Leola"},
+	})
+
+	tests := []struct {
+		name     string
+		field    string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "number",
+			field:    "_id",
+			value:    "2",
+			expected: []string{"2"},
+		},
+		{
+			name:     "string",
+			field:    "name",
+			value:    "Francis Bailey",
+			expected: []string{"1"},
+		},
+		{
+			name:     "boolean",
+			field:    "active",
+			value:    "true",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "array_element_without_duplicates",
+			field:    "tags",
+			value:    "Leola",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:  "array_elements_are_not_matched_by_substring",
+			field: "tags",
+			value: "Leo",
+		},
+		{
+			name:  "null_is_not_indexed",
+			field: "alias",
+			value: "",
+		},
+		{
+			name:  "objects_are_not_indexed",
+			field: "logo",
+			value: "map[url:logo.png]",
+		},
+		{
+			name:  "unknown_field",
+			field: "unknown",
+			value: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, fi.lookup(tt.field, tt.value))
+		})
+	}
+}
+
+func TestFieldIndex_Remove(t *testing.T) {
+	first := map[string]interface{}{"_id": float64(1), "tags": []interface{}{"Leola", "Veguita"}}
+	second := map[string]interface{}{"_id": float64(2), "tags": []interface{}{"Leola"}}
+
+	fi := fieldIndex{}
+	fi.add("1", first)
+	fi.add("2", second)
+	fi.remove("1", first)
+
+	assert.Equal(t, []string{"2"}, fi.lookup("tags", "Leola"))
+	assert.Empty(t, fi.lookup("tags", "Veguita"))
+	assert.Empty(t, fi.lookup("_id", "1"))
+
+	fi.remove("2", second)
+	assert.Empty(t, fi)
+}
@@ -0,0 +1,108 @@
+package store
+
+// Duplicate is an ID that was loaded more than once for an entity. Only the last
+// record with the ID is kept in the store.
+type Duplicate struct {
+	Entity string
+	ID     string
+	Count  int
+}
+
+// DanglingReference is a field of a record holding the ID of a record that doesn't
+// exist, e.g. a ticket with a submitter_id that is not the _id of any user.
+type DanglingReference struct {
+	Entity string
+	ID     string
+	Field  string
+	Target string
+	Value  string
+}
+
+// IntegrityReport lists the problems found in the relationships between the entities
+// of the store along with the number of records per entity.
+type IntegrityReport struct {
+	Records            map[string]int
+	Duplicates         []Duplicate
+	DanglingReferences []DanglingReference
+}
+
+// OK is true when no problems were found
+func (r IntegrityReport) OK() bool {
+	return len(r.Duplicates) == 0 && len(r.DanglingReferences) == 0
+}
+
+// Count returns the number of dangling references of entity in field
+func (r IntegrityReport) Count(entity, field string) int {
+	n := 0
+	for _, ref := range r.DanglingReferences {
+		if ref.Entity == entity && ref.Field == field {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Check verifies that the fields of the relations of every entity, like the
+// organization_id, submitter_id and assignee_id of tickets, resolve to existing
+// records, and reports the IDs that were loaded more than once. Problems are listed
+// per entity in the order the records were loaded.
+func (s *Storage) Check() IntegrityReport {
+	report := IntegrityReport{Records: map[string]int{}}
+	for _, name := range s.order {
+		e := s.entities[name]
+		report.Records[name] = len(e.records)
+		report.Duplicates = append(report.Duplicates, e.index.findDuplicates(name)...)
+	}
+
+	for _, name := range s.order {
+		for _, key := range s.entities[name].index.ids {
+			for _, l := range s.links {
+				if l.source == name {
+					report.DanglingReferences = append(report.DanglingReferences, s.checkLink(l, key)...)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// checkLink returns the values of the field of the record of the source of l with the
+// key that are not in the field of any record of the target
+func (s *Storage) checkLink(l link, key string) []DanglingReference {
+	record, _ := s.Record(l.source, key)
+	targetIndex, _ := s.index(l.target)
+
+	var refs []DanglingReference
+	for _, value := range indexValues(record[l.field]) {
+		if len(targetIndex.fields.lookup(l.targetField, value)) > 0 {
+			continue
+		}
+
+		refs = append(refs, DanglingReference{
+			Entity: l.source,
+			ID:     key,
+			Field:  l.field,
+			Target: l.target,
+			Value:  value,
+		})
+	}
+
+	return refs
+}
+
+// findDuplicates returns the IDs that were added more than once in load order
+func (ei *entityIndex) findDuplicates(entity string) []Duplicate {
+	var duplicates []Duplicate
+	for _, id := range ei.ids {
+		n, ok := ei.duplicates[id]
+		if !ok {
+			continue
+		}
+
+		duplicates = append(duplicates, Duplicate{Entity: entity, ID: id, Count: n + 1})
+	}
+
+	return duplicates
+}
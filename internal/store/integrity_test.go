@@ -0,0 +1,75 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestStorage_Check(t *testing.T) {
+	s := New(readOrgs(t), readUsers(t), readTickets(t))
+
+	report := s.Check()
+	assert.Equal(t, map[string]int{"organizations": 2, "users": 2, "tickets": 2}, report.Records)
+	assert.Empty(t, report.Duplicates)
+	assert.Equal(t, []DanglingReference{
+		{Entity: "tickets", ID: "c68cb7d7-b517-4d0b-a826-9605423e78c2", Field: "organization_id", Target: "organizations", Value: "120"},
+	}, report.DanglingReferences)
+}
+
+func TestStorage_CheckProblems(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze"}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(1), "name": "Francis Bailey", "organization_id": float64(101)}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(2), "name": "Cross Barlow", "organization_id": float64(999)}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(3), "name": "No Org"}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "organization_id": float64(101), "submitter_id": float64(1), "assignee_id": float64(404)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "b", "organization_id": float64(102), "submitter_id": float64(405)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "organization_id": float64(101), "submitter_id": float64(1)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "organization_id": float64(101), "submitter_id": float64(1)}))
+
+	report := b.Build().Check()
+	require.False(t, report.OK())
+
+	assert.Equal(t, map[string]int{"organizations": 1, "users": 3, "tickets": 2}, report.Records)
+	assert.Equal(t, []Duplicate{{Entity: "tickets", ID: "a", Count: 3}}, report.Duplicates)
+	assert.Equal(t, []DanglingReference{
+		{Entity: "users", ID: "2", Field: "organization_id", Target: "organizations", Value: "999"},
+		{Entity: "tickets", ID: "b", Field: "organization_id", Target: "organizations", Value: "102"},
+		{Entity: "tickets", ID: "b", Field: "submitter_id", Target: "users", Value: "405"},
+	}, report.DanglingReferences)
+	assert.Equal(t, 1, report.Count("tickets", "submitter_id"))
+	assert.Equal(t, 0, report.Count("tickets", "assignee_id"))
+}
+
+func TestBuilder_DuplicateReplacesRecord(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze"}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(1), "name": "Francis Bailey", "organization_id": float64(101)}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(1), "name": "Cross Barlow"}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "subject": "old", "organization_id": float64(101), "assignee_id": float64(1)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "a", "subject": "new"}))
+
+	s := b.Build()
+
+	_, err := s.Users(query.Term{Field: "name", Value: "Francis Bailey"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	users, err := s.Users(query.Term{Field: "name", Value: "Cross Barlow"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Empty(t, users[0].AssignedTickets)
+
+	_, err = s.Tickets(query.Term{Field: "subject", Value: "old"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	orgs, err := s.Organizations(query.Term{Field: "_id", Value: "101"})
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Empty(t, orgs[0].UserNames)
+	assert.Empty(t, orgs[0].TicketSubjects)
+}
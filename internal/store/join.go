@@ -0,0 +1,73 @@
+package store
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+// relation links the records of an entity to the records of the target entity.
+// related returns the IDs of the records related to a record of the target.
+type relation struct {
+	target  *entityIndex
+	related func(id string) []string
+}
+
+// joinTo creates a relation to target
+func joinTo(target *entityIndex, related func(id string) []string) relation {
+	return relation{target: target, related: related}
+}
+
+// eval searches the target with expr and returns the IDs of the records related to
+// the ones found
+func (r relation) eval(expr query.Expr) (idSet, error) {
+	found, err := r.target.eval(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.relatedSet(found), nil
+}
+
+// relatedSet returns the IDs of the records related to the IDs of the target
+func (r relation) relatedSet(ids idSet) idSet {
+	set := idSet{}
+	for id := range ids {
+		for _, key := range r.related(id) {
+			set[key] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// join evaluates a query.Join using the relation of the entity it names
+func (ei *entityIndex) join(j query.Join) (idSet, error) {
+	r, err := ei.relation(j.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.eval(j.Expr)
+}
+
+func (ei *entityIndex) relation(name string) (relation, error) {
+	r, ok := ei.joins[name]
+	if !ok {
+		return relation{}, invalidQuery("unknown relation %q, must be one of %s", name, ei.relations())
+	}
+
+	return r, nil
+}
+
+func (ei *entityIndex) relations() string {
+	names := make([]string, 0, len(ei.joins))
+	for name := range ei.joins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
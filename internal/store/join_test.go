@@ -0,0 +1,105 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestStorage_Joins(t *testing.T) {
+	s := New(
+		model.Organizations{
+			{"_id": float64(101), "name": "Enthaze", "tags": []interface{}{"West"}},
+			{"_id": float64(102), "name": "Nutralab", "tags": []interface{}{"East"}},
+		},
+		model.Users{
+			{"_id": float64(1), "name": "Francis Bailey", "role": "admin", "organization_id": float64(101)},
+			{"_id": float64(2), "name": "Cross Barlow", "role": "agent", "organization_id": float64(102)},
+			{"_id": float64(3), "name": "Rose Newton", "role": "admin"},
+		},
+		model.Tickets{
+			{"_id": "a", "status": "open", "organization_id": float64(101), "submitter_id": float64(2), "assignee_id": float64(1)},
+			{"_id": "b", "status": "open", "organization_id": float64(101), "submitter_id": float64(1), "assignee_id": float64(2)},
+			{"_id": "c", "status": "closed", "organization_id": float64(102), "submitter_id": float64(3), "assignee_id": float64(3)},
+			{"_id": "d", "status": "open", "organization_id": float64(102)},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		entity   string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "tickets_by_organization_and_assignee",
+			entity:   "tickets",
+			query:    "organization.tags:West AND assignee.role:admin AND status:open",
+			expected: []string{"a"},
+		},
+		{
+			name:     "tickets_not_assigned_to_admins",
+			entity:   "tickets",
+			query:    "NOT assignee.role:admin",
+			expected: []string{"b", "d"},
+		},
+		{
+			name:     "tickets_by_submitter_organization",
+			entity:   "tickets",
+			query:    "submitter.organization.name:Nutralab",
+			expected: []string{"a"},
+		},
+		{
+			name:     "users_by_organization",
+			entity:   "users",
+			query:    "organization.tags:East OR organization.tags:West",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "users_by_any_submitted_ticket",
+			entity:   "users",
+			query:    "submitted_tickets.status:open",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "users_by_assigned_ticket",
+			entity:   "users",
+			query:    "assigned_tickets.status:closed",
+			expected: []string{"3"},
+		},
+		{
+			name:     "organizations_by_users_and_tickets",
+			entity:   "organizations",
+			query:    "users.role:admin AND tickets.status:closed",
+			expected: []string{},
+		},
+		{
+			name:     "organizations_by_users_range",
+			entity:   "organizations",
+			query:    "users._id:>=2",
+			expected: []string{"102"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			ei, ok := s.index(tt.entity)
+			require.True(t, ok)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	_, err := s.Tickets(query.Join{Relation: "organisation", Expr: query.Term{Field: "name", Value: "Enthaze"}})
+	require.EqualError(t, err, `unknown relation "organisation", must be one of assignee, organization, submitter`)
+	require.ErrorIs(t, err, ErrInvalidQuery)
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+// Live is a Storage that can be replaced by a new one while it is being searched,
+// e.g. after the data files are reloaded. Every search uses the Storage that was
+// current when it started, so searches in flight finish against the old one.
+// Records are changed in place, so changes wait for the searches of the current
+// Storage to finish and searches wait for the change.
+type Live struct {
+	v  atomic.Value
+	mu sync.RWMutex
+}
+
+// NewLive creates a Live searching s
+func NewLive(s *Storage) *Live {
+	l := &Live{}
+	l.v.Store(s)
+
+	return l
+}
+
+// Storage returns the current Storage
+func (l *Live) Storage() *Storage {
+	return l.v.Load().(*Storage)
+}
+
+// Swap replaces the current Storage with s, searches started afterwards use s
+func (l *Live) Swap(s *Storage) {
+	l.v.Store(s)
+}
+
+// read returns the current Storage locked for reading and the function unlocking it
+func (l *Live) read() (*Storage, func()) {
+	l.mu.RLock()
+
+	return l.Storage(), l.mu.RUnlock
+}
+
+// write returns the current Storage locked for changing it and the function unlocking it
+func (l *Live) write() (*Storage, func()) {
+	l.mu.Lock()
+
+	return l.Storage(), l.mu.Unlock
+}
+
+// GetSearchableFields returns the fields of the current Storage, see Storage.GetSearchableFields
+func (l *Live) GetSearchableFields() map[string][]string {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.GetSearchableFields()
+}
+
+// Suggest returns values close to value in the current Storage, see Storage.Suggest
+func (l *Live) Suggest(entity, field, value string) []string {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Suggest(entity, field, value)
+}
+
+// Aggregate summarizes the records of the current Storage, see Storage.Aggregate
+func (l *Live) Aggregate(entity string, agg Aggregation) (Stats, error) {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Aggregate(entity, agg)
+}
+
+// Schema describes the fields of the current Storage, see Storage.Schema
+func (l *Live) Schema() map[string][]FieldSchema {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Schema()
+}
+
+// Check reports the problems of the records of the current Storage, see Storage.Check
+func (l *Live) Check() IntegrityReport {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Check()
+}
+
+// Record returns a record of the current Storage, see Storage.Record
+func (l *Live) Record(entity, id string) (map[string]interface{}, bool) {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Record(entity, id)
+}
+
+// Records returns the records of an entity of the current Storage, see Storage.Records
+func (l *Live) Records(entity string) ([]map[string]interface{}, error) {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Records(entity)
+}
+
+// Insert stores a new record in the current Storage, see Storage.Insert
+func (l *Live) Insert(entity string, record map[string]interface{}) error {
+	s, unlock := l.write()
+	defer unlock()
+
+	return s.Insert(entity, record)
+}
+
+// Update replaces a record of the current Storage, see Storage.Update
+func (l *Live) Update(entity string, record map[string]interface{}) error {
+	s, unlock := l.write()
+	defer unlock()
+
+	return s.Update(entity, record)
+}
+
+// Delete removes a record from the current Storage, see Storage.Delete
+func (l *Live) Delete(entity, id string) error {
+	s, unlock := l.write()
+	defer unlock()
+
+	return s.Delete(entity, id)
+}
+
+// Search evaluates the query against the current Storage, see Storage.Search
+func (l *Live) Search(entity string, q query.Expr) ([]model.Result, error) {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Search(entity, q)
+}
+
+// Entities describes the entities of the current Storage, see Storage.Entities
+func (l *Live) Entities() []EntityConfig {
+	s, unlock := l.read()
+	defer unlock()
+
+	return s.Entities()
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestLive_Swap(t *testing.T) {
+	old := New(nil, model.Users{{"_id": float64(1), "name": "Francis Bailey"}}, nil)
+	live := NewLive(old)
+
+	q := query.Term{Field: "name", Value: "Cross Barlow"}
+	_, err := live.Search("users", q)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	live.Swap(New(nil, model.Users{{"_id": float64(2), "name": "Cross Barlow"}}, nil))
+
+	results, err := live.Search("users", q)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, float64(2), results[0].Fields()["_id"])
+
+	// the previous snapshot is left untouched for searches that still use it
+	_, err = old.Users(query.Term{Field: "name", Value: "Francis Bailey"})
+	require.NoError(t, err)
+}
+
+func TestLive_Insert(t *testing.T) {
+	live := NewLive(New(nil, model.Users{{"_id": float64(1), "name": "Francis Bailey"}}, nil))
+
+	var wg sync.WaitGroup
+	for i := 2; i < 50; i++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			assert.NoError(t, live.Insert("users", map[string]interface{}{"_id": float64(id), "name": "Cross Barlow"}))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := live.Search("users", query.Term{Field: "name", Value: "Francis Bailey"})
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	results, err := live.Search("users", query.Term{Field: "name", Value: "Cross Barlow"})
+	require.NoError(t, err)
+	assert.Len(t, results, 48)
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrExists is returned when inserting a record with the ID of a record that is stored
+var ErrExists = errors.New("already exists")
+
+// Records are inserted, updated and deleted in place, so unlike searches they must not
+// run concurrently with any other method of the Storage, Live changes them while it is
+// searched. Every index and relationship
+// is kept up to date, a record that is updated keeps its position in the results.
+
+// Insert stores and indexes a new record of the entity. Returns ErrExists when the
+// entity has a record with the same ID and the model.FieldErrors of the record if it
+// is not valid.
+func (s *Storage) Insert(entity string, record map[string]interface{}) error {
+	return s.put(entity, record, false)
+}
+
+// Update replaces the record of the entity with the same ID as record. Returns
+// ErrNotFound when there is no such record and the model.FieldErrors of the record if
+// it is not valid.
+func (s *Storage) Update(entity string, record map[string]interface{}) error {
+	return s.put(entity, record, true)
+}
+
+// Delete removes the record of the entity with the ID. Records related to it keep
+// referencing it, Check reports them. Returns ErrNotFound when there is no such record.
+func (s *Storage) Delete(entity, id string) error {
+	e, ok := s.entities[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity: %s", entity)
+	}
+
+	key := e.key(id)
+	old, ok := e.records[key]
+	if !ok {
+		return fmt.Errorf("%s %s: %w", entity, id, ErrNotFound)
+	}
+
+	e.index.remove(key, old)
+	e.index.delete(key)
+	delete(e.records, key)
+
+	return nil
+}
+
+// Record returns the record of the entity with the ID
+func (s *Storage) Record(entity, id string) (map[string]interface{}, bool) {
+	e, ok := s.entities[entity]
+	if !ok {
+		return nil, false
+	}
+
+	record, ok := e.records[e.key(id)]
+	return record, ok
+}
+
+// Records returns the records of the entity in the order they were loaded or inserted
+func (s *Storage) Records(entity string) ([]map[string]interface{}, error) {
+	e, ok := s.entities[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity: %s", entity)
+	}
+
+	records := make([]map[string]interface{}, 0, len(e.index.ids))
+	for _, key := range e.index.ids {
+		records = append(records, e.records[key])
+	}
+
+	return records, nil
+}
+
+// put inserts the record or, when replace is true, updates it
+func (s *Storage) put(entity string, record map[string]interface{}, replace bool) error {
+	e, ok := s.entities[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity: %s", entity)
+	}
+
+	key, err := e.recordKey(record)
+	if err != nil {
+		return err
+	}
+
+	old, ok := e.records[key]
+	if err := checkExists(entity, key, ok, replace); err != nil {
+		return err
+	}
+
+	if ok {
+		e.index.remove(key, old)
+	}
+
+	e.records[key] = record
+	e.index.put(key, record)
+
+	return nil
+}
+
+// checkExists returns an error when a record is found and it is not replaced, or it
+// is not found and it should be
+func checkExists(entity, id string, found, replace bool) error {
+	switch {
+	case found && !replace:
+		return fmt.Errorf("%s %s: %w", entity, id, ErrExists)
+	case !found && replace:
+		return fmt.Errorf("%s %s: %w", entity, id, ErrNotFound)
+	default:
+		return nil
+	}
+}
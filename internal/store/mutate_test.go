@@ -0,0 +1,124 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func newMutateStorage() *Storage {
+	return New(
+		model.Organizations{
+			{"_id": float64(101), "name": "Enthaze", "tags": []interface{}{"West"}},
+			{"_id": float64(102), "name": "Nutralab"},
+		},
+		model.Users{
+			{"_id": float64(1), "name": "Francis Bailey", "organization_id": float64(101)},
+			{"_id": float64(2), "name": "Cross Barlow", "organization_id": float64(101)},
+		},
+		model.Tickets{
+			{"_id": "27c447d9", "subject": "A Problem in Guyana", "status": "open", "assignee_id": float64(1), "organization_id": float64(101)},
+			{"_id": "7382ad0e", "subject": "A Nuisance in Kiribati", "status": "open", "assignee_id": float64(2)},
+		},
+	)
+}
+
+func searchIDs(t *testing.T, s *Storage, entity, input string) []string {
+	t.Helper()
+
+	q, err := query.Parse(input)
+	require.NoError(t, err)
+
+	ei, _ := s.index(entity)
+	ids, _, err := ei.search(q)
+	require.NoError(t, err)
+
+	return ids
+}
+
+func TestStorage_Insert(t *testing.T) {
+	s := newMutateStorage()
+
+	err := s.Insert("users", map[string]interface{}{"_id": float64(3), "name": "Rose Newton", "organization_id": float64(102)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "2", "3"}, searchIDs(t, s, "users", "NOT name:nobody"))
+	assert.Equal(t, []string{"3"}, searchIDs(t, s, "users", "text:rose"))
+	assert.Equal(t, []string{"102"}, searchIDs(t, s, "organizations", "users.name:\"Rose Newton\""))
+
+	err = s.Insert("users", map[string]interface{}{"_id": float64(1), "name": "Someone"})
+	require.ErrorIs(t, err, ErrExists)
+	assert.Equal(t, "users 1: already exists", err.Error())
+
+	err = s.Insert("users", map[string]interface{}{"name": "Nobody"})
+	var fieldErrs model.FieldErrors
+	require.ErrorAs(t, err, &fieldErrs)
+
+	require.Error(t, s.Insert("groups", map[string]interface{}{"_id": float64(1)}))
+}
+
+func TestStorage_Update(t *testing.T) {
+	s := newMutateStorage()
+
+	ticket, ok := s.Record("tickets", "27c447d9")
+	require.True(t, ok)
+
+	updated := map[string]interface{}{}
+	for field, v := range ticket {
+		updated[field] = v
+	}
+
+	updated["assignee_id"] = float64(2)
+	updated["status"] = "solved"
+	require.NoError(t, s.Update("tickets", updated))
+
+	// the ticket keeps its position and its previous values are not found anymore
+	assert.Equal(t, []string{"27c447d9", "7382ad0e"}, searchIDs(t, s, "tickets", "assignee_id:2"))
+	assert.Empty(t, searchIDs(t, s, "tickets", "status:open AND assignee_id:1"))
+	assert.Equal(t, []string{"27c447d9"}, searchIDs(t, s, "tickets", "status:solved"))
+	assert.Empty(t, searchIDs(t, s, "users", "assigned_tickets.status:open AND _id:1"))
+
+	users, err := s.Users(query.Term{Field: "_id", Value: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A Nuisance in Kiribati", "A Problem in Guyana"}, users[0].AssignedTickets)
+
+	assert.Empty(t, s.Check().Duplicates)
+
+	err = s.Update("tickets", map[string]interface{}{"_id": "unknown"})
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "tickets unknown: not found", err.Error())
+}
+
+func TestStorage_Delete(t *testing.T) {
+	s := newMutateStorage()
+
+	require.NoError(t, s.Delete("users", "1.0"))
+
+	_, ok := s.Record("users", "1")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"2"}, searchIDs(t, s, "users", "NOT name:nobody"))
+	assert.Equal(t, []string{"2"}, searchIDs(t, s, "users", "organization_id:101"))
+
+	orgs, err := s.Organizations(query.Term{Field: "_id", Value: "101"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Cross Barlow"}, orgs[0].UserNames)
+
+	records, err := s.Records("users")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"_id": float64(2), "name": "Cross Barlow", "organization_id": float64(101)},
+	}, records)
+
+	// the ticket assigned to the deleted user references a user that doesn't exist
+	assert.Len(t, s.Check().DanglingReferences, 1)
+
+	require.NoError(t, s.Delete("tickets", "27c447d9"))
+	assert.Equal(t, []string{"7382ad0e"}, searchIDs(t, s, "tickets", "text:nuisance"))
+
+	require.ErrorIs(t, s.Delete("users", "1"), ErrNotFound)
+	require.ErrorIs(t, s.Delete("organizations", "Enthaze"), ErrNotFound)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
 )
 
 func TestStorage_Organizations(t *testing.T) {
@@ -96,7 +97,7 @@ func TestStorage_Organizations(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(tt.orgData, tt.userData, tt.ticketData)
-			got, err := s.Organizations(tt.term, tt.value)
+			got, err := s.Organizations(query.Term{Field: tt.term, Value: tt.value})
 			if tt.expectedError != nil {
 				require.EqualError(t, err, tt.expectedError.Error())
 				return
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+// entityIndex holds the inverted index of an entity along with the IDs of all its
+// records in the order they were loaded. The full list of IDs is needed to evaluate
+// NOT and the order is used to return results in a stable order.
+// duplicates counts how many times each ID was added again after the first time and
+// joins are the relations to other entities that can be searched by name, e.g. organization.
+type entityIndex struct {
+	fields     fieldIndex
+	text       *textIndex
+	ranges     rangeIndex
+	fuzzy      fuzzyIndex
+	blanks     *blankIndex
+	types      schemaIndex
+	joins      map[string]relation
+	ids        []string
+	order      map[string]int
+	duplicates map[string]int
+}
+
+// newEntityIndex creates an index that adds textFields to its full-text index
+func newEntityIndex(textFields []textField) *entityIndex {
+	return &entityIndex{
+		fields:     fieldIndex{},
+		text:       newTextIndex(textFields),
+		blanks:     newBlankIndex(),
+		types:      schemaIndex{},
+		order:      map[string]int{},
+		duplicates: map[string]int{},
+	}
+}
+
+// add indexes the record under id and keeps track of the order it was added in.
+// Adding an ID that already exists counts it as a duplicate, the caller is expected
+// to remove the values of the previous record first.
+func (ei *entityIndex) add(id string, record map[string]interface{}) {
+	if _, ok := ei.order[id]; ok {
+		ei.duplicates[id]++
+	}
+
+	ei.put(id, record)
+}
+
+// put indexes the record under id like add, but an ID that already exists is replaced
+// in its position without counting it as a duplicate
+func (ei *entityIndex) put(id string, record map[string]interface{}) {
+	if _, ok := ei.order[id]; !ok {
+		ei.order[id] = len(ei.ids)
+		ei.ids = append(ei.ids, id)
+	}
+
+	ei.fields.add(id, record)
+	ei.text.add(id, record)
+	ei.blanks.add(id, record)
+	ei.types.add(record)
+	ei.ranges.reset()
+	ei.fuzzy.reset()
+}
+
+// remove deletes the values of the record from the indexes. The ID is kept so it
+// doesn't lose its position when a duplicate replaces it.
+func (ei *entityIndex) remove(id string, record map[string]interface{}) {
+	ei.fields.remove(id, record)
+	ei.text.remove(id, record)
+	ei.blanks.remove(id, record)
+	ei.types.remove(record)
+	ei.ranges.reset()
+	ei.fuzzy.reset()
+}
+
+// delete forgets the ID of a record that is deleted, its values must be removed first
+func (ei *entityIndex) delete(id string) {
+	k, ok := ei.order[id]
+	if !ok {
+		return
+	}
+
+	ei.ids = append(ei.ids[:k], ei.ids[k+1:]...)
+	delete(ei.order, id)
+	delete(ei.duplicates, id)
+	for ; k < len(ei.ids); k++ {
+		ei.order[ei.ids[k]] = k
+	}
+}
+
+// search evaluates expr against the index and returns the IDs of the matching
+// records in the order they were loaded. When expr has text or fuzzy queries the
+// records are ranked by their score instead, which is returned along with the IDs.
+func (ei *entityIndex) search(expr query.Expr) ([]string, map[string]float64, error) {
+	set, err := ei.eval(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+
+	var scores map[string]float64
+	if tokens := ei.text.rankedTokens(expr); len(tokens) > 0 {
+		scores = ei.text.score(tokens, ids, len(ei.ids))
+	}
+
+	for id, score := range ei.fuzzyScores(expr, ids) {
+		if scores == nil {
+			scores = map[string]float64{}
+		}
+
+		scores[id] += score
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+
+		return ei.order[ids[i]] < ei.order[ids[j]]
+	})
+
+	return ids, scores, nil
+}
+
+type idSet map[string]struct{}
+
+func (ei *entityIndex) eval(expr query.Expr) (idSet, error) {
+	switch e := expr.(type) {
+	case query.Term:
+		return ei.evalTerm(e)
+	case query.Text:
+		return ei.text.search(e.Value), nil
+	case query.Range:
+		return ei.lookupRange(e)
+	case query.Is:
+		return ei.lookupIs(e)
+	case query.Join:
+		return ei.join(e)
+	case query.And:
+		return ei.evalAnd(e)
+	case query.Or:
+		return ei.evalOr(e)
+	case query.Not:
+		return ei.evalNot(e)
+	default:
+		return nil, fmt.Errorf("unsupported query expression: %T", expr)
+	}
+}
+
+func (ei *entityIndex) evalTerm(e query.Term) (idSet, error) {
+	if e.Mode == query.Fuzzy {
+		set := idSet{}
+		for id := range ei.lookupFuzzy(e) {
+			set[id] = struct{}{}
+		}
+
+		return set, nil
+	}
+
+	if e.Mode != "" && e.Mode != query.Exact {
+		matches, err := e.Matcher()
+		if err != nil {
+			return nil, invalidQuery("%s", err)
+		}
+
+		return ei.fields.match(e.Field, matches), nil
+	}
+
+	ids := ei.fields.lookup(e.Field, e.Value)
+	set := make(idSet, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+
+	return set, nil
+}
+
+func (ei *entityIndex) evalAnd(e query.And) (idSet, error) {
+	left, right, err := ei.evalBoth(e.Left, e.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	// iterate over the smallest set
+	if len(left) > len(right) {
+		left, right = right, left
+	}
+
+	set := idSet{}
+	for id := range left {
+		if _, ok := right[id]; ok {
+			set[id] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+func (ei *entityIndex) evalOr(e query.Or) (idSet, error) {
+	left, right, err := ei.evalBoth(e.Left, e.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range right {
+		left[id] = struct{}{}
+	}
+
+	return left, nil
+}
+
+func (ei *entityIndex) evalNot(e query.Not) (idSet, error) {
+	excluded, err := ei.eval(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(idSet, len(ei.ids)-len(excluded))
+	for _, id := range ei.ids {
+		if _, ok := excluded[id]; !ok {
+			set[id] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+func (ei *entityIndex) evalBoth(left, right query.Expr) (idSet, idSet, error) {
+	leftSet, err := ei.eval(left)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rightSet, err := ei.eval(right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return leftSet, rightSet, nil
+}
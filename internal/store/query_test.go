@@ -0,0 +1,406 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestEntityIndex_Search(t *testing.T) {
+	ei := newEntityIndex(textFields["tickets"])
+	ei.add("1", map[string]interface{}{"status": "open", "priority": "high", "tags": []interface{}{"Ohio"},
+		"subject": "A Catastrophe in Korea", "description": "The printer is broken"})
+	ei.add("2", map[string]interface{}{"status": "open", "priority": "urgent", "tags": []interface{}{"Texas"},
+		"subject": "Printers are not printing"})
+	ei.add("3", map[string]interface{}{"status": "closed", "priority": "high", "tags": []interface{}{"Texas"},
+		"subject": "A Problem in Korea", "description": nil})
+	ei.add("4", map[string]interface{}{"status": "open", "priority": "low", "subject": []interface{}{"Broken", "Korea"}})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "term",
+			query:    "status:open",
+			expected: []string{"1", "2", "4"},
+		},
+		{
+			name:     "and",
+			query:    "status:open AND priority:high",
+			expected: []string{"1"},
+		},
+		{
+			name:     "or_keeps_load_order",
+			query:    "priority:urgent OR priority:high",
+			expected: []string{"1", "2", "3"},
+		},
+		{
+			name:     "not",
+			query:    "NOT tags:Texas",
+			expected: []string{"1", "4"},
+		},
+		{
+			name:     "nested",
+			query:    "status:open AND (priority:high OR priority:urgent) AND NOT tags:Ohio",
+			expected: []string{"2"},
+		},
+		{
+			name:     "text",
+			query:    "text:korea",
+			expected: []string{"1", "3", "4"},
+		},
+		{
+			name:     "text_all_words_across_fields",
+			query:    `text:"korea printer"`,
+			expected: []string{"1"},
+		},
+		{
+			name:     "text_stemmed_ranked_by_frequency",
+			query:    "text:PRINTED",
+			expected: []string{"2", "1"},
+		},
+		{
+			name:     "text_ranks_subject_above_description",
+			query:    "text:broken AND status:open",
+			expected: []string{"4", "1"},
+		},
+		{
+			name:     "not_text_is_not_ranked",
+			query:    "NOT text:printer",
+			expected: []string{"3", "4"},
+		},
+		{
+			name:     "text_stop_words",
+			query:    `text:"the in"`,
+			expected: []string{},
+		},
+		{
+			name:     "no_match",
+			query:    "status:pending",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestEntityIndex_RemoveText(t *testing.T) {
+	ei := newEntityIndex([]textField{{name: "subject", boost: 1}})
+	ticket := map[string]interface{}{"subject": "Printer broken"}
+	ei.add("1", ticket)
+	ei.remove("1", ticket)
+	ei.add("1", map[string]interface{}{"subject": "Printer fixed"})
+
+	got, _, err := ei.search(query.Text{Value: "broken"})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	got, _, err = ei.search(query.Text{Value: "printer"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, got)
+	assert.Equal(t, 2, ei.text.lengths["subject"]["1"])
+}
+
+func TestTextIndex_Score(t *testing.T) {
+	ti := newTextIndex([]textField{{name: "subject", boost: 2}, {name: "description", boost: 1}})
+	ti.add("subject", map[string]interface{}{"subject": "Printer broken", "description": "Call support"})
+	ti.add("description", map[string]interface{}{"subject": "Call support", "description": "Printer broken"})
+	ti.add("long", map[string]interface{}{"subject": "Printer broken, office on fire, coffee machine empty"})
+	ti.add("none", map[string]interface{}{"subject": "Nothing to see"})
+
+	scores := ti.score(ti.analyzer.Tokens("printer"), []string{"subject", "description", "long"}, 4)
+	assert.Greater(t, scores["subject"], scores["long"], "shorter fields rank higher")
+	assert.Greater(t, scores["subject"], scores["description"], "subject is boosted")
+	assert.NotContains(t, scores, "none")
+
+	rare := ti.score(ti.analyzer.Tokens("coffee"), []string{"long"}, 4)
+	assert.Greater(t, rare["long"], scores["long"], "rare words are worth more")
+}
+
+func TestEntityIndex_Range(t *testing.T) {
+	ei := newEntityIndex(nil)
+	ei.add("1", map[string]interface{}{"_id": float64(1), "due_at": "2016-07-31T02:37:50 -10:00", "scores": []interface{}{float64(3), float64(30)}})
+	ei.add("2", map[string]interface{}{"_id": float64(2), "due_at": "2016-08-15T05:37:32 +10:00"})
+	ei.add("10", map[string]interface{}{"_id": float64(10), "due_at": "2016-08-15T05:37:32 -10:00", "scores": []interface{}{float64(10)}})
+	ei.add("20", map[string]interface{}{"_id": float64(20), "due_at": nil})
+	ei.add("x", map[string]interface{}{"_id": "x", "due_at": "soon"})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "between_inclusive_numerically",
+			query:    "_id:2..10",
+			expected: []string{"2", "10"},
+		},
+		{
+			name:     "greater_than",
+			query:    "_id:>2",
+			expected: []string{"10", "20"},
+		},
+		{
+			name:     "less_or_equal",
+			query:    "_id:<=2",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "timestamps_compare_instants",
+			query:    `due_at:<"2016-08-15T05:37:32 -10:00"`,
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "date_without_time",
+			query:    "due_at:>=2016-08-01",
+			expected: []string{"2", "10"},
+		},
+		{
+			name:     "array_elements",
+			query:    "scores:>5",
+			expected: []string{"1", "10"},
+		},
+		{
+			name:     "not_range_includes_missing",
+			query:    "NOT _id:1..10",
+			expected: []string{"20", "x"},
+		},
+		{
+			name:     "kind_mismatch",
+			query:    "due_at:>5",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	// sorted values are discarded when records change
+	ei.add("5", map[string]interface{}{"_id": float64(5)})
+	got, _, err := ei.search(query.Range{Field: "_id", Min: &query.Bound{Value: "4"}, Max: &query.Bound{Value: "6"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5"}, got)
+
+	_, _, err = ei.search(query.Range{Field: "_id", Min: &query.Bound{Value: "soon"}})
+	require.EqualError(t, err, "invalid range _id:>soon")
+}
+
+func TestEntityIndex_Is(t *testing.T) {
+	ei := newEntityIndex(nil)
+	ei.add("1", map[string]interface{}{"alias": "Miss Singleton", "assignee_id": float64(1), "tags": []interface{}{"Ohio"}})
+	ei.add("2", map[string]interface{}{"alias": "", "assignee_id": nil, "tags": []interface{}{}})
+	ei.add("3", map[string]interface{}{"alias": nil, "tags": []interface{}{nil}})
+	ei.add("4", map[string]interface{}{})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "null",
+			query:    "alias:is:null OR assignee_id:is:null",
+			expected: []string{"2", "3"},
+		},
+		{
+			name:     "missing",
+			query:    "assignee_id:is:missing",
+			expected: []string{"3", "4"},
+		},
+		{
+			name:     "null_array_elements_are_not_missing",
+			query:    "tags:is:missing",
+			expected: []string{"4"},
+		},
+		{
+			name:     "empty_string_and_array",
+			query:    "alias:is:empty OR tags:is:empty",
+			expected: []string{"2"},
+		},
+		{
+			name:     "unknown_field_is_missing_everywhere",
+			query:    "details:is:missing",
+			expected: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:     "not_null_and_not_missing",
+			query:    "NOT assignee_id:is:null AND NOT assignee_id:is:missing",
+			expected: []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	ei.remove("2", map[string]interface{}{"alias": "", "assignee_id": nil, "tags": []interface{}{}})
+	got, _, err := ei.search(query.Is{Field: "alias", Predicate: query.IsEmpty})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestEntityIndex_Match(t *testing.T) {
+	ei := newEntityIndex(nil)
+	ei.add("1", map[string]interface{}{"_id": float64(1), "name": "Francisca Rasmussen", "tags": []interface{}{"Springville", "Sutton"}})
+	ei.add("2", map[string]interface{}{"_id": float64(2), "name": "Cross Barlow", "tags": []interface{}{"Foxworth"}})
+	ei.add("10", map[string]interface{}{"_id": float64(10), "name": "francis bailey"})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "exact_is_case_sensitive",
+			query:    `name:exact:"Cross barlow"`,
+			expected: []string{},
+		},
+		{
+			name:     "nocase",
+			query:    `name:nocase:"CROSS BARLOW"`,
+			expected: []string{"2"},
+		},
+		{
+			name:     "prefix",
+			query:    "name:prefix:Fran",
+			expected: []string{"1"},
+		},
+		{
+			name:     "prefix_numbers_as_text",
+			query:    "_id:prefix:1",
+			expected: []string{"1", "10"},
+		},
+		{
+			name:     "glob_array_elements",
+			query:    "tags:glob:S*ton",
+			expected: []string{"1"},
+		},
+		{
+			name:     "glob_single_character",
+			query:    `name:glob:"?ranc*"`,
+			expected: []string{"1", "10"},
+		},
+		{
+			name:     "regex_is_unanchored",
+			query:    `name:regex:"(?i)bar(low|ley)"`,
+			expected: []string{"2"},
+		},
+		{
+			name:     "regex_unknown_field",
+			query:    "email:regex:.",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	_, _, err := ei.search(query.Term{Field: "name", Value: "(", Mode: query.Regex})
+	require.Error(t, err)
+}
+
+func TestEntityIndex_Fuzzy(t *testing.T) {
+	ei := newEntityIndex(nil)
+	ei.add("1", map[string]interface{}{"name": "Francisca Rasmussen", "email": "coffeyrasmussen@flotonic.com"})
+	ei.add("2", map[string]interface{}{"name": "Francis Bailey", "email": "francisbailey@flotonic.com"})
+	ei.add("3", map[string]interface{}{"name": "Frances Barlow", "email": "fbarlow@zentix.com"})
+	ei.add("4", map[string]interface{}{"name": "Jo"})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "ranked_by_distance",
+			query:    "name:fuzzy:fransis",
+			expected: []string{"2", "3"},
+		},
+		{
+			name:     "whole_value",
+			query:    `name:fuzzy:"francis baily"`,
+			expected: []string{"2"},
+		},
+		{
+			name:     "email_words",
+			query:    "email:fuzzy:flotnic",
+			expected: []string{"1", "2"},
+		},
+		{
+			name:     "short_values_must_be_exact",
+			query:    "name:fuzzy:JO OR name:fuzzy:ja",
+			expected: []string{"4"},
+		},
+		{
+			name:     "not_is_not_ranked",
+			query:    "NOT name:fuzzy:barlo",
+			expected: []string{"1", "2", "4"},
+		},
+		{
+			name:     "too_many_typos",
+			query:    "name:fuzzy:frnsss",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := query.Parse(tt.query)
+			require.NoError(t, err)
+
+			got, _, err := ei.search(expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	_, scores, err := ei.search(query.Term{Field: "name", Value: "Francis", Mode: query.Fuzzy})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"1": 1.0 / 3, "2": 1, "3": 0.5}, scores)
+
+	assert.Equal(t, []string{"Francis Bailey"}, ei.suggest("name", "Francis Baily", 3))
+	assert.Equal(t, []string{"Frances Barlow", "Francis Bailey"}, ei.suggest("name", "Frances", 3))
+	assert.Equal(t, []string{"Frances Barlow"}, ei.suggest("name", "Frances", 1))
+	assert.Empty(t, ei.suggest("name", "Francis Bailey", 3))
+
+	// the trigram index is rebuilt when records change
+	ei.add("5", map[string]interface{}{"name": "Francis Baileys"})
+	assert.Equal(t, []string{"Francis Baileys"}, ei.suggest("name", "Francis Bailey", 3))
+}
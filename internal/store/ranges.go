@@ -0,0 +1,136 @@
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+// orderedValue is a value of a field along with its position in the order of the
+// values of its kind, see query.ParseOrdered
+type orderedValue struct {
+	key   float64
+	value string
+}
+
+// sortedField holds the distinct values of a field that are numbers or timestamps,
+// sorted per kind so a range is a binary search over them
+type sortedField map[query.Kind][]orderedValue
+
+func newSortedField(values map[string][]string) sortedField {
+	sf := sortedField{}
+	for value := range values {
+		key, kind, ok := query.ParseOrdered(value)
+		if !ok {
+			continue
+		}
+
+		sf[kind] = append(sf[kind], orderedValue{key: key, value: value})
+	}
+
+	for _, ordered := range sf {
+		ordered := ordered
+		sort.Slice(ordered, func(i, j int) bool {
+			if ordered[i].key != ordered[j].key {
+				return ordered[i].key < ordered[j].key
+			}
+
+			return ordered[i].value < ordered[j].value
+		})
+	}
+
+	return sf
+}
+
+// rangeIndex keeps the sorted values of the fields used in range queries. Fields are
+// sorted the first time they are queried, since most fields never are, and sorted again
+// after the records change.
+type rangeIndex struct {
+	m      sync.Mutex
+	fields map[string]sortedField
+}
+
+// sorted returns the sorted values of field, sorting them if needed
+func (ri *rangeIndex) sorted(field string, values map[string][]string) sortedField {
+	ri.m.Lock()
+	defer ri.m.Unlock()
+
+	if sf, ok := ri.fields[field]; ok {
+		return sf
+	}
+
+	if ri.fields == nil {
+		ri.fields = map[string]sortedField{}
+	}
+
+	sf := newSortedField(values)
+	ri.fields[field] = sf
+
+	return sf
+}
+
+// reset discards the sorted values after the records change
+func (ri *rangeIndex) reset() {
+	ri.m.Lock()
+	defer ri.m.Unlock()
+
+	ri.fields = nil
+}
+
+// lookupRange returns the IDs of the records with a value of the field within the range.
+// Values of a different kind than the bounds, e.g. numbers in a timestamp range, never match.
+func (ei *entityIndex) lookupRange(r query.Range) (idSet, error) {
+	var kind query.Kind
+	var min, max float64
+	for _, bound := range []*query.Bound{r.Min, r.Max} {
+		if bound == nil {
+			continue
+		}
+
+		key, k, ok := query.ParseOrdered(bound.Value)
+		if !ok || (kind != 0 && k != kind) {
+			return nil, invalidQuery("invalid range %s", r)
+		}
+
+		kind = k
+		if bound == r.Min {
+			min = key
+		} else {
+			max = key
+		}
+	}
+
+	values := ei.ranges.sorted(r.Field, ei.fields[r.Field])[kind]
+
+	start := 0
+	if r.Min != nil {
+		start = sort.Search(len(values), func(i int) bool {
+			if r.Min.Inclusive {
+				return values[i].key >= min
+			}
+
+			return values[i].key > min
+		})
+	}
+
+	end := len(values)
+	if r.Max != nil {
+		end = sort.Search(len(values), func(i int) bool {
+			if r.Max.Inclusive {
+				return values[i].key > max
+			}
+
+			return values[i].key >= max
+		})
+	}
+
+	set := idSet{}
+	for k := start; k < end; k++ {
+		for _, id := range ei.fields.lookup(r.Field, values[k].value) {
+			set[id] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JSON types of the values of a field
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+	TypeNull    = "null"
+)
+
+// FieldSchema describes the values found in a field across all the records of an entity
+type FieldSchema struct {
+	Name string `json:"name"`
+	// Types are the JSON types of the values, sorted by name
+	Types []string `json:"types"`
+	// Records is the number of records with the field, including null values
+	Records int `json:"records"`
+	Nulls   int `json:"nulls"`
+	// Missing is the number of records without the field
+	Missing int `json:"missing"`
+	// Distinct is the number of distinct values, array elements are counted one by one
+	Distinct int `json:"distinct"`
+}
+
+// schemaIndex counts the records of an entity per field and JSON type of its value,
+// so the fields are the union of the fields of all the records
+type schemaIndex map[string]map[string]int
+
+// add counts the type of every field of the record
+func (si schemaIndex) add(record map[string]interface{}) {
+	for field, v := range record {
+		types, ok := si[field]
+		if !ok {
+			types = map[string]int{}
+			si[field] = types
+		}
+
+		types[jsonType(v)]++
+	}
+}
+
+// remove stops counting the fields of a record that is replaced
+func (si schemaIndex) remove(record map[string]interface{}) {
+	for field, v := range record {
+		types := si[field]
+		typ := jsonType(v)
+
+		types[typ]--
+		if types[typ] <= 0 {
+			delete(types, typ)
+		}
+
+		if len(types) == 0 {
+			delete(si, field)
+		}
+	}
+}
+
+// fields returns the names of the fields sorted
+func (si schemaIndex) fields() []string {
+	fields := make([]string, 0, len(si))
+	for field := range si {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return TypeString
+	case float64, int:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	case []interface{}:
+		return TypeArray
+	case map[string]interface{}:
+		return TypeObject
+	case nil:
+		return TypeNull
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// schema describes every field found in the records of the entity, sorted by name
+func (ei *entityIndex) schema() []FieldSchema {
+	fields := ei.types.fields()
+	schema := make([]FieldSchema, 0, len(fields))
+	for _, field := range fields {
+		fs := FieldSchema{
+			Name:     field,
+			Nulls:    ei.types[field][TypeNull],
+			Distinct: len(ei.fields[field]),
+		}
+
+		for typ, n := range ei.types[field] {
+			fs.Types = append(fs.Types, typ)
+			fs.Records += n
+		}
+
+		sort.Strings(fs.Types)
+		fs.Missing = len(ei.ids) - fs.Records
+		schema = append(schema, fs)
+	}
+
+	return schema
+}
+
+// Schema describes the fields of every entity, found across all of their records
+func (s *Storage) Schema() map[string][]FieldSchema {
+	schema := map[string][]FieldSchema{}
+	for name, e := range s.entities {
+		schema[name] = e.index.schema()
+	}
+
+	return schema
+}
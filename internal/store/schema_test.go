@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+)
+
+func TestStorage_Schema(t *testing.T) {
+	b := NewBuilder()
+	require.NoError(t, b.AddUser(model.User{"_id": float64(1), "name": "Francis Bailey", "tags": []interface{}{"Ohio", "Texas"}, "score": float64(1)}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(2), "name": "Cross Barlow", "alias": nil, "tags": []interface{}{"Ohio"}}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(3), "alias": "Miss Rose", "score": "high"}))
+	// replaces the previous user 3, its fields are not counted anymore
+	require.NoError(t, b.AddUser(model.User{"_id": float64(3), "alias": "Miss Rose", "signature": "Don't Worry Be Happy!", "score": float64(2)}))
+	s := b.Build()
+
+	expected := []FieldSchema{
+		{Name: "_id", Types: []string{TypeNumber}, Records: 3, Distinct: 3},
+		{Name: "alias", Types: []string{TypeNull, TypeString}, Records: 2, Nulls: 1, Missing: 1, Distinct: 1},
+		{Name: "name", Types: []string{TypeString}, Records: 2, Missing: 1, Distinct: 2},
+		{Name: "score", Types: []string{TypeNumber}, Records: 2, Missing: 1, Distinct: 2},
+		{Name: "signature", Types: []string{TypeString}, Records: 1, Missing: 2, Distinct: 1},
+		{Name: "tags", Types: []string{TypeArray}, Records: 2, Missing: 1, Distinct: 2},
+	}
+
+	schema := s.Schema()
+	assert.Equal(t, expected, schema["users"])
+	assert.Empty(t, schema["tickets"])
+	assert.Equal(t, []string{"_id", "alias", "name", "score", "signature", "tags"}, s.GetSearchableFields()["users"])
+}
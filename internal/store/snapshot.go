@@ -0,0 +1,272 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SnapshotVersion is the version of the snapshot format written by WriteSnapshot. It must
+// be increased whenever the Storage or its indexes change, so older snapshots are rejected
+// instead of being decoded into the wrong structures.
+const SnapshotVersion = 2
+
+// snapshotMagic identifies a snapshot file
+var snapshotMagic = [4]byte{'Z', 'I', 'D', 'X'}
+
+var (
+	// ErrNotSnapshot is returned when reading a file that is not a snapshot
+	ErrNotSnapshot = errors.New("not a zearch index snapshot")
+	// ErrSnapshotChecksum is returned when the contents of a snapshot are corrupted
+	ErrSnapshotChecksum = errors.New("index snapshot checksum mismatch, the file is corrupted")
+)
+
+// SnapshotVersionError is returned when reading a snapshot written in another format version
+type SnapshotVersionError struct {
+	Version uint32
+}
+
+// Error returns the version of the snapshot and the version that is supported
+func (e *SnapshotVersionError) Error() string {
+	return fmt.Sprintf("index snapshot format version %d is not supported, expected version %d", e.Version, SnapshotVersion)
+}
+
+// snapshotHeader is written before the encoded snapshot, the checksum is the SHA-256
+// of the encoded snapshot
+type snapshotHeader struct {
+	Magic    [4]byte
+	Version  uint32
+	Checksum [sha256.Size]byte
+}
+
+// snapshot holds the records and indexes of a Storage. The indexes that are built the
+// first time they are queried, like ranges and fuzzy, are not stored.
+// Records are stored as JSON arrays in the order of the IDs of their index, since gob
+// doesn't tell an empty array from null apart. Entities is the entities config the
+// entities are created with before their records and indexes are restored.
+type snapshot struct {
+	Stem     bool
+	Entities []EntityConfig
+	Records  map[string][]byte
+	Indexes  map[string]entitySnapshot
+}
+
+// entitySnapshot holds an entityIndex, sets are stored as lists of IDs since gob
+// can't encode empty structs
+type entitySnapshot struct {
+	Fields     fieldIndex
+	Postings   map[string]map[string]map[string]int
+	Lengths    map[string]map[string]int
+	Totals     map[string]int
+	Nulls      map[string][]string
+	Empties    map[string][]string
+	Unindexed  map[string][]string
+	Types      schemaIndex
+	IDs        []string
+	Duplicates map[string]int
+}
+
+func init() {
+	// the concrete types of the values of the records, basic types are registered by gob
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// WriteSnapshot writes the records and indexes of the Storage to w, so it can be loaded
+// with ReadSnapshot without reading and indexing the data files again
+func (s *Storage) WriteSnapshot(w io.Writer) error {
+	snap := snapshot{
+		Stem:     s.Stemming(),
+		Entities: s.configs,
+		Records:  map[string][]byte{},
+		Indexes:  map[string]entitySnapshot{},
+	}
+
+	for name, e := range s.entities {
+		records := make([]map[string]interface{}, 0, len(e.index.ids))
+		for _, key := range e.index.ids {
+			records = append(records, e.records[key])
+		}
+
+		encoded, err := json.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+
+		snap.Records[name] = encoded
+		snap.Indexes[name] = e.index.snapshot()
+	}
+
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(snap); err != nil {
+		return fmt.Errorf("encode index snapshot: %w", err)
+	}
+
+	header := snapshotHeader{
+		Magic:    snapshotMagic,
+		Version:  SnapshotVersion,
+		Checksum: sha256.Sum256(payload.Bytes()),
+	}
+
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return err
+	}
+
+	_, err := payload.WriteTo(w)
+	return err
+}
+
+// ReadSnapshot reads a Storage written by WriteSnapshot. Returns ErrNotSnapshot,
+// a *SnapshotVersionError or ErrSnapshotChecksum when r can't be read as a snapshot.
+func ReadSnapshot(r io.Reader) (*Storage, error) {
+	var header snapshotHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotSnapshot
+		}
+
+		return nil, err
+	}
+
+	if header.Magic != snapshotMagic {
+		return nil, ErrNotSnapshot
+	}
+
+	if header.Version != SnapshotVersion {
+		return nil, &SnapshotVersionError{Version: header.Version}
+	}
+
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if sha256.Sum256(payload) != header.Checksum {
+		return nil, ErrSnapshotChecksum
+	}
+
+	var snap snapshot
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("decode index snapshot: %w", err)
+	}
+
+	s := &Storage{stem: snap.Stem}
+	if err := s.setEntities(snap.Entities); err != nil {
+		return nil, fmt.Errorf("decode entities config: %w", err)
+	}
+
+	for name, e := range s.entities {
+		var records []map[string]interface{}
+		if err := json.Unmarshal(snap.Records[name], &records); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+
+		index := snap.Indexes[name]
+		for k, record := range records {
+			e.records[index.IDs[k]] = record
+		}
+
+		e.index.merge(index)
+		e.index.restore(index, snap.Stem)
+	}
+
+	return s, nil
+}
+
+// Stemming reports whether the full-text indexes use stemming, see Builder.SetStemming
+func (s *Storage) Stemming() bool {
+	return s.stem
+}
+
+func (ei *entityIndex) snapshot() entitySnapshot {
+	return entitySnapshot{
+		Fields:     ei.fields,
+		Postings:   ei.text.postings,
+		Lengths:    ei.text.lengths,
+		Totals:     ei.text.totals,
+		Nulls:      setLists(ei.blanks.nulls),
+		Empties:    setLists(ei.blanks.empties),
+		Unindexed:  setLists(ei.blanks.unindexed),
+		Types:      ei.types,
+		IDs:        ei.ids,
+		Duplicates: ei.duplicates,
+	}
+}
+
+// restore sets the IDs and sets of the index from a snapshot, see merge
+func (ei *entityIndex) restore(snap entitySnapshot, stem bool) {
+	ei.text.analyzer.Stem = stem
+	ei.blanks.nulls = setsFromLists(snap.Nulls)
+	ei.blanks.empties = setsFromLists(snap.Empties)
+	ei.blanks.unindexed = setsFromLists(snap.Unindexed)
+
+	ei.ids = snap.IDs
+	for k, id := range ei.ids {
+		ei.order[id] = k
+	}
+}
+
+// merge copies the maps of a snapshot into the maps of the index, gob doesn't decode
+// into the maps of the indexes since they are created from the decoded config
+func (ei *entityIndex) merge(snap entitySnapshot) {
+	for field, values := range snap.Fields {
+		ei.fields[field] = values
+	}
+
+	for field, postings := range snap.Postings {
+		if postings != nil {
+			ei.text.postings[field] = postings
+		}
+	}
+
+	for field, lengths := range snap.Lengths {
+		if lengths != nil {
+			ei.text.lengths[field] = lengths
+		}
+	}
+
+	for field, total := range snap.Totals {
+		ei.text.totals[field] = total
+	}
+
+	for field, types := range snap.Types {
+		ei.types[field] = types
+	}
+
+	for id, n := range snap.Duplicates {
+		ei.duplicates[id] = n
+	}
+}
+
+func setLists(sets map[string]idSet) map[string][]string {
+	lists := make(map[string][]string, len(sets))
+	for field, set := range sets {
+		ids := make([]string, 0, len(set))
+		for id := range set {
+			ids = append(ids, id)
+		}
+
+		lists[field] = ids
+	}
+
+	return lists
+}
+
+func setsFromLists(lists map[string][]string) map[string]idSet {
+	sets := make(map[string]idSet, len(lists))
+	for field, ids := range lists {
+		set := make(idSet, len(ids))
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+
+		sets[field] = set
+	}
+
+	return sets
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
+)
+
+func TestSnapshot(t *testing.T) {
+	b := NewBuilder()
+	b.SetStemming(false)
+	require.NoError(t, b.AddOrganization(model.Organization{"_id": float64(101), "name": "Enthaze", "tags": []interface{}{"West"}}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(1), "name": "Francis Bailey", "organization_id": float64(101), "alias": nil}))
+	require.NoError(t, b.AddUser(model.User{"_id": float64(2), "name": "Cross Barlow", "organization_id": float64(102), "tags": []interface{}{}}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "27c447d9", "subject": "Printers in Guyana", "submitter_id": float64(1), "score": float64(3)}))
+	require.NoError(t, b.AddTicket(model.Ticket{"_id": "27c447d9", "subject": "A Problem in Guyana", "submitter_id": float64(2), "score": float64(5)}))
+	s := b.Build()
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteSnapshot(&buf))
+
+	loaded, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	assert.False(t, loaded.Stemming())
+	for _, entity := range []string{"organizations", "users", "tickets"} {
+		expected, err := s.Records(entity)
+		require.NoError(t, err)
+		records, err := loaded.Records(entity)
+		require.NoError(t, err)
+		assert.Equal(t, expected, records, entity)
+	}
+	assert.Equal(t, s.GetSearchableFields(), loaded.GetSearchableFields())
+	assert.Equal(t, s.Schema(), loaded.Schema())
+	assert.Equal(t, s.Check(), loaded.Check())
+
+	queries := []string{
+		"name:Enthaze",
+		"organization.name:Enthaze",
+		"alias:is:null",
+		"tags:is:empty",
+		"NOT organization_id:is:missing",
+		"text:guyana",
+		"score:>=4",
+		"name:fuzzy:fransis",
+	}
+	for _, input := range queries {
+		q, err := query.Parse(input)
+		require.NoError(t, err)
+
+		for _, entity := range []string{"organizations", "users", "tickets"} {
+			ei, _ := s.index(entity)
+			expectedIDs, expectedScores, expectedErr := ei.search(q)
+
+			ei, _ = loaded.index(entity)
+			ids, scores, err := ei.search(q)
+
+			assert.Equal(t, expectedErr, err, "%s %s", entity, input)
+			assert.Equal(t, expectedIDs, ids, "%s %s", entity, input)
+			assert.Equal(t, expectedScores, scores, "%s %s", entity, input)
+		}
+	}
+
+	// the relations between records are kept too
+	expected, err := s.Users(query.Term{Field: "name", Value: "Cross Barlow"})
+	require.NoError(t, err)
+	users, err := loaded.Users(query.Term{Field: "name", Value: "Cross Barlow"})
+	require.NoError(t, err)
+	assert.Equal(t, expected, users)
+}
+
+func TestSnapshot_Entities(t *testing.T) {
+	s := newEntitiesStorage(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteSnapshot(&buf))
+
+	loaded, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	assert.Equal(t, testEntities, loaded.Config())
+	assert.Equal(t, s.Entities(), loaded.Entities())
+	assert.Equal(t, s.Schema(), loaded.Schema())
+	assert.Equal(t, s.Check(), loaded.Check())
+
+	for _, input := range []string{"text:agents", "organization.name:Enthaze", "tickets._id:b", "NOT name:Sales"} {
+		q, err := query.Parse(input)
+		require.NoError(t, err)
+
+		expected, expectedErr := s.Search("groups", q)
+		results, err := loaded.Search("groups", q)
+		assert.Equal(t, expectedErr, err, input)
+		assert.Equal(t, expected, results, input)
+	}
+
+	// records inserted after loading are indexed like the ones in the snapshot
+	require.NoError(t, loaded.Insert("groups", map[string]interface{}{"id": float64(4), "name": "Agents Abroad"}))
+	results, err := loaded.Search("groups", query.Text{Value: "agents"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestReadSnapshot_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, New(nil, model.Users{{"_id": float64(1)}}, nil).WriteSnapshot(&buf))
+	valid := buf.Bytes()
+
+	_, err := ReadSnapshot(bytes.NewReader([]byte(`[{"_id": 1}]`)))
+	assert.ErrorIs(t, err, ErrNotSnapshot)
+
+	newer := append([]byte{}, valid...)
+	newer[7] = SnapshotVersion + 1
+	_, err = ReadSnapshot(bytes.NewReader(newer))
+	var versionErr *SnapshotVersionError
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, "index snapshot format version 3 is not supported, expected version 2", err.Error())
+
+	// snapshots written before the entities of the config were added
+	older := append([]byte{}, valid...)
+	older[7] = 1
+	_, err = ReadSnapshot(bytes.NewReader(older))
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, uint32(1), versionErr.Version)
+	assert.Equal(t, "index snapshot format version 1 is not supported, expected version 2", err.Error())
+
+	corrupted := append([]byte{}, valid...)
+	corrupted[len(corrupted)-1]++
+	_, err = ReadSnapshot(bytes.NewReader(corrupted))
+	assert.ErrorIs(t, err, ErrSnapshotChecksum)
+}
@@ -2,8 +2,7 @@ package store
 
 import (
 	"errors"
-	"sort"
-	"sync"
+	"fmt"
 
 	"github.com/jaimem88/zearch/internal/model"
 )
@@ -11,143 +10,93 @@ import (
 // ErrNotFound returned when any search cannot find any match
 var ErrNotFound = errors.New("not found")
 
+// ErrInvalidQuery is matched by the errors of queries that can't be evaluated, e.g.
+// they search an unknown entity or join an unknown relation
+var ErrInvalidQuery = errors.New("invalid query")
+
+// queryError is a mistake in a query, it keeps its message and is ErrInvalidQuery
+type queryError struct {
+	msg string
+}
+
+func invalidQuery(format string, args ...interface{}) error {
+	return queryError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e queryError) Error() string {
+	return e.msg
+}
+
+func (e queryError) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
+
 // Storage holds an in-memory set of maps that will be used to store and lookup
 // values per key
 type Storage struct {
-	// store the users, tickets and organizations by ID so that accessing them
-	// is done in constant time
-	usersMap         map[model.UserID]model.User
-	ticketsMap       map[model.TicketID]model.Ticket
-	organizationsMap map[model.OrgID]model.Organization
-
-	// Keep a list of users and tickets per orgID
-	orgsUsers   map[model.OrgID][]model.UserID
-	orgsTickets map[model.OrgID][]model.TicketID
-
-	searchableFields map[string][]string
+	// entities by name store their records by ID so that accessing them is done in
+	// constant time, along with the inverted index used to search by any other term.
+	// order keeps organizations, users and tickets first and then the entities of the
+	// config in the order they were defined. links are the relations between the
+	// records of the entities, see Builder.SetEntities.
+	entities map[string]*entity
+	order    []string
+	configs  []EntityConfig
+	links    []link
+
+	// stem is whether the full-text indexes use stemming, see Builder.SetStemming
+	stem bool
 }
 
 // New creates an instance of Storage and preprocess the data to store it in its
-// corresponding data structures.
-// The initialization process for every entity will be done on startup. Each entity is
-// loaded in its own goroutine, using a sync.WaitGroup to wait for all of them to finish.
+// corresponding data structures. Every field of every record is added to the
+// inverted index of its entity.
+// Records that are not valid are skipped, model.StreamFiles reports them when they
+// are loaded from the data files.
 func New(organizations model.Organizations, users model.Users, tickets model.Tickets) *Storage {
-	orgsMap := map[model.OrgID]model.Organization{}
-	usersMap := map[model.UserID]model.User{}
-	ticketsMap := map[model.TicketID]model.Ticket{}
-
-	orgsUsers := map[model.OrgID][]model.UserID{}
-	orgsTickets := map[model.OrgID][]model.TicketID{}
-	var m sync.Mutex
-	searchableFields := map[string][]string{}
-
-	wg := sync.WaitGroup{}
-	wg.Add(3)
-
-	go func() {
-		defer wg.Done()
-
-		for k, org := range organizations {
-			// assumes there are no duplicate IDs, otherwise the data would be overridden
-			// unsafe to do type assertions without checking if it succeeded, but assuming it's correct for simplicity
-			orgID := model.OrgID(org["_id"].(float64))
-			orgsMap[orgID] = org
-
-			// Get the searchable fields from the first element programmatically. The caveat to this approach is that
-			// if other objects have more fields they won't be printed as searchable.
-			if k == 0 {
-				m.Lock()
-				searchableFields["organizations"] = getOrgFields(org)
-				m.Unlock()
-			}
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		for k, user := range users {
-			userID := model.UserID(user["_id"].(float64))
-			usersMap[userID] = user
-
-			orgID, ok := user["organization_id"].(float64)
-			if ok {
-				orgID := model.OrgID(orgID)
-				orgsUsers[orgID] = append(orgsUsers[orgID], userID)
-			}
-
-			if k == 0 {
-				m.Lock()
-				searchableFields["users"] = getUserFields(user)
-				m.Unlock()
-			}
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		for k, ticket := range tickets {
-			ticketID := model.TicketID(ticket["_id"].(string))
-			ticketsMap[ticketID] = ticket
-
-			orgID, ok := ticket["organization_id"].(float64)
-			if ok {
-				orgID := model.OrgID(orgID)
-				orgsTickets[orgID] = append(orgsTickets[orgID], ticketID)
-			}
-
-			if k == 0 {
-				m.Lock()
-				searchableFields["tickets"] = getTicketFields(ticket)
-				m.Unlock()
-			}
-		}
-	}()
-
-	wg.Wait()
-
-	return &Storage{
-		usersMap:         usersMap,
-		ticketsMap:       ticketsMap,
-		organizationsMap: orgsMap,
-		orgsUsers:        orgsUsers,
-		orgsTickets:      orgsTickets,
-		searchableFields: searchableFields,
+	b := NewBuilder()
+
+	for _, org := range organizations {
+		_ = b.AddOrganization(org)
 	}
-}
 
-func getOrgFields(org model.Organization) []string {
-	fields := make([]string, 0, len(org))
-	for k := range org {
-		fields = append(fields, k)
+	for _, user := range users {
+		_ = b.AddUser(user)
 	}
 
-	sort.Strings(fields)
-	return fields
+	for _, ticket := range tickets {
+		_ = b.AddTicket(ticket)
+	}
+
+	return b.Build()
 }
 
-func getUserFields(user model.User) []string {
-	fields := make([]string, 0, len(user))
-	for k := range user {
-		fields = append(fields, k)
-	}
+// newStorage creates a Storage with organizations, users and tickets and no records
+func newStorage() *Storage {
+	s := &Storage{stem: true}
 
-	sort.Strings(fields)
-	return fields
+	// the built-in entities are always valid
+	_ = s.setEntities(nil)
+
+	return s
 }
 
-func getTicketFields(ticket model.Ticket) []string {
-	fields := make([]string, 0, len(ticket))
-	for k := range ticket {
-		fields = append(fields, k)
+// index returns the inverted index of the entity
+func (s *Storage) index(entity string) (*entityIndex, bool) {
+	e, ok := s.entities[entity]
+	if !ok {
+		return nil, false
 	}
 
-	sort.Strings(fields)
-	return fields
+	return e.index, true
 }
 
-// GetSearchableFields returns the list of fields per entity contained in the store
+// GetSearchableFields returns the fields found in any record of each entity, sorted by name
 func (s *Storage) GetSearchableFields() map[string][]string {
-	return s.searchableFields
+	fields := map[string][]string{}
+	for name, e := range s.entities {
+		fields[name] = e.index.types.fields()
+	}
+
+	return fields
 }
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"math"
+
+	"github.com/jaimem88/zearch/internal/query"
+	"github.com/jaimem88/zearch/internal/text"
+)
+
+// BM25 parameters, k1 limits how much repeating a word increases the score and b
+// how much longer fields are penalised
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// textField is a free-text field and the weight of its matches when ranking results
+type textField struct {
+	name  string
+	boost float64
+}
+
+// textFields are the free-text fields of every entity that are added to its full-text
+// index. Matches in short fields that describe the record, like a ticket subject, rank
+// higher than matches in longer fields.
+var textFields = map[string][]textField{
+	"organizations": {{name: "name", boost: 2}, {name: "details", boost: 1}},
+	"users":         {{name: "name", boost: 2}, {name: "alias", boost: 1.5}, {name: "signature", boost: 1}},
+	"tickets":       {{name: "subject", boost: 2}, {name: "description", boost: 1}},
+}
+
+// textIndex is a full-text index over the free-text fields of an entity. It maps the
+// tokens of every field to the IDs of the records containing them and how many times
+// they appear, along with the number of tokens of every field used to rank results.
+type textIndex struct {
+	analyzer text.Analyzer
+	fields   []textField
+	postings map[string]map[string]map[string]int
+	lengths  map[string]map[string]int
+	totals   map[string]int
+}
+
+func newTextIndex(fields []textField) *textIndex {
+	ti := &textIndex{
+		analyzer: text.Analyzer{Stem: true},
+		fields:   fields,
+		postings: map[string]map[string]map[string]int{},
+		lengths:  map[string]map[string]int{},
+		totals:   map[string]int{},
+	}
+
+	for _, field := range fields {
+		ti.postings[field.name] = map[string]map[string]int{}
+		ti.lengths[field.name] = map[string]int{}
+	}
+
+	return ti
+}
+
+// add indexes the tokens of the text fields of the record under id
+func (ti *textIndex) add(id string, record map[string]interface{}) {
+	for _, field := range ti.fields {
+		tokens := ti.tokens(record[field.name])
+		if len(tokens) == 0 {
+			continue
+		}
+
+		postings := ti.postings[field.name]
+		for _, token := range tokens {
+			ids, ok := postings[token]
+			if !ok {
+				ids = map[string]int{}
+				postings[token] = ids
+			}
+
+			ids[id]++
+		}
+
+		ti.lengths[field.name][id] += len(tokens)
+		ti.totals[field.name] += len(tokens)
+	}
+}
+
+// remove deletes the tokens of the record from the index
+func (ti *textIndex) remove(id string, record map[string]interface{}) {
+	for _, field := range ti.fields {
+		postings := ti.postings[field.name]
+		for _, token := range ti.tokens(record[field.name]) {
+			delete(postings[token], id)
+			if len(postings[token]) == 0 {
+				delete(postings, token)
+			}
+		}
+
+		ti.totals[field.name] -= ti.lengths[field.name][id]
+		delete(ti.lengths[field.name], id)
+	}
+}
+
+// tokens analyzes a string field, array fields are analyzed per element
+func (ti *textIndex) tokens(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return ti.analyzer.Tokens(v)
+	case []interface{}:
+		var tokens []string
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				tokens = append(tokens, ti.analyzer.Tokens(s)...)
+			}
+		}
+
+		return tokens
+	default:
+		return nil
+	}
+}
+
+// search returns the IDs of the records containing every token of value in any of
+// their text fields. Nothing matches when value only has stop words.
+func (ti *textIndex) search(value string) idSet {
+	tokens := ti.analyzer.Tokens(value)
+	if len(tokens) == 0 {
+		return idSet{}
+	}
+
+	var set idSet
+	for _, token := range tokens {
+		matches := idSet{}
+		for _, field := range ti.fields {
+			for id := range ti.postings[field.name][token] {
+				if set == nil {
+					matches[id] = struct{}{}
+					continue
+				}
+
+				if _, ok := set[id]; ok {
+					matches[id] = struct{}{}
+				}
+			}
+		}
+
+		set = matches
+		if len(set) == 0 {
+			break
+		}
+	}
+
+	return set
+}
+
+// score ranks the records with the given IDs by how relevant they are to the tokens
+// using BM25. The score of every token is the sum of the BM25 score of each field
+// multiplied by the boost of the field. n is the total number of records of the entity.
+func (ti *textIndex) score(tokens []string, ids []string, n int) map[string]float64 {
+	scores := make(map[string]float64, len(ids))
+	if n == 0 {
+		return scores
+	}
+
+	for _, token := range tokens {
+		idf := ti.idf(token, n)
+
+		for _, field := range ti.fields {
+			postings := ti.postings[field.name][token]
+			if len(postings) == 0 {
+				continue
+			}
+
+			avgLength := float64(ti.totals[field.name]) / float64(n)
+			for _, id := range ids {
+				tf, ok := postings[id]
+				if !ok {
+					continue
+				}
+
+				length := float64(ti.lengths[field.name][id])
+				norm := bm25K1 * (1 - bm25B + bm25B*length/avgLength)
+				scores[id] += field.boost * idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + norm)
+			}
+		}
+	}
+
+	return scores
+}
+
+// idf is the inverse document frequency of the token, rare tokens are worth more than
+// common ones. A record counts once even if the token appears in several fields.
+func (ti *textIndex) idf(token string, n int) float64 {
+	docs := idSet{}
+	for _, field := range ti.fields {
+		for id := range ti.postings[field.name][token] {
+			docs[id] = struct{}{}
+		}
+	}
+
+	df := float64(len(docs))
+
+	return math.Log(1 + (float64(n)-df+0.5)/(df+0.5))
+}
+
+// rankedTokens returns the tokens of the text queries of expr that are used to rank
+// the results. Text queries inside NOT don't rank since matching records are excluded.
+func (ti *textIndex) rankedTokens(expr query.Expr) []string {
+	switch e := expr.(type) {
+	case query.Text:
+		return ti.analyzer.Tokens(e.Value)
+	case query.And:
+		return append(ti.rankedTokens(e.Left), ti.rankedTokens(e.Right)...)
+	case query.Or:
+		return append(ti.rankedTokens(e.Left), ti.rankedTokens(e.Right)...)
+	default:
+		return nil
+	}
+}
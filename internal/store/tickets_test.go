@@ -8,15 +8,17 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
 )
 
 func TestStorage_Tickets(t *testing.T) {
 	orgData := readOrgs(t)
+	userData := readUsers(t)
 	ticketData := readTickets(t)
 
 	tests := []struct {
-		name string
-		//userData       model.Users
+		name           string
+		userData       model.Users
 		ticketData     model.Tickets
 		orgData        model.Organizations
 		term           string
@@ -63,6 +65,20 @@ func TestStorage_Tickets(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:       "search_by_id_with_submitter_and_assignee",
+			userData:   userData,
+			ticketData: ticketData,
+			term:       "_id",
+			value:      "27c447d9-cfda-4415-9a72-d5aa12942cf1",
+			expectedResult: []*model.TicketResult{
+				{
+					Ticket:        ticketData[0],
+					SubmitterName: "Francis Bailey",
+					AssigneeName:  "Francis Bailey",
+				},
+			},
+		},
 		{
 			name:       "search_by_has_incidents_boolean",
 			ticketData: ticketData,
@@ -92,8 +108,8 @@ func TestStorage_Tickets(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := New(tt.orgData, nil, tt.ticketData)
-			got, err := s.Tickets(tt.term, tt.value)
+			s := New(tt.orgData, tt.userData, tt.ticketData)
+			got, err := s.Tickets(query.Term{Field: tt.term, Value: tt.value})
 			if tt.expectedError != nil {
 				require.EqualError(t, err, tt.expectedError.Error())
 				return
@@ -110,6 +126,8 @@ func TestStorage_Tickets(t *testing.T) {
 			for k, expectedResult := range tt.expectedResult {
 				assert.Equal(t, expectedResult.Ticket["_id"], got[k].Ticket["_id"])
 				assert.Equal(t, expectedResult.OrganizationName, got[k].OrganizationName)
+				assert.Equal(t, expectedResult.SubmitterName, got[k].SubmitterName)
+				assert.Equal(t, expectedResult.AssigneeName, got[k].AssigneeName)
 			}
 		})
 	}
@@ -1,140 +1,29 @@
 package store
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
-
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
 )
 
-// Users implements the searcher method for the app. It searches by term and value.
-// Handles a special case for _id which can be looked up in the Storage easily from the
-// usersMap.
-func (s *Storage) Users(term, value string) ([]model.UserResult, error) {
-	fmt.Printf("Searching users by: %q with value: %q\n", term, value)
-
-	if term == "_id" {
-		return s.searchUserByID(value)
-	}
-
-	return s.searchUserByTerm(term, value)
-}
-
-func (s *Storage) searchUserByID(value string) ([]model.UserResult, error) {
-	var results []model.UserResult
-
-	id, err := strconv.Atoi(value)
-	if err != nil {
-		return nil, err
-	}
-
-	userID := model.UserID(id)
-
-	user, ok := s.usersMap[userID]
-	if !ok {
-		return nil, ErrNotFound
-	}
-
-	orgID := getUserOrgID(user)
-	userResult := model.UserResult{
-		User:             user,
-		OrganizationName: s.getOrgName(orgID),
-		TicketSubjects:   s.getTicketsForOrg(orgID),
-	}
-
-	results = append(results, userResult)
-
-	return results, nil
-}
-
-func getUserOrgID(user model.User) model.OrgID {
-	orgID, ok := user["organization_id"].(float64)
-	if !ok {
-		orgID = 0
-	}
-
-	return model.OrgID(orgID)
-}
-
-// searchUserByTerm will iterate over each element of the usersMap and accessing
-// the term directly. Once found, the user and its ID will be saved in a slice to later fetch
-// the related tickets and users.
-func (s *Storage) searchUserByTerm(term, value string) ([]model.UserResult, error) {
-	type userAndID struct {
-		id   model.UserID
-		user model.User
-	}
+// Users evaluates the query against the users index like Search and returns the
+// results as model.UserResult whatever the config displays.
+func (s *Storage) Users(q query.Expr) ([]model.UserResult, error) {
 	var result []model.UserResult
-	var foundUsers []*userAndID
-
-	// search all users for a match in a specific field
-	for userID, user := range s.usersMap {
-		if user[term] == nil {
-			continue
-		}
-
-		if findUserMatch(user, term, value) {
-			foundUsers = append(foundUsers, &userAndID{
-				id:   userID,
-				user: user,
-			})
-		}
-	}
-
-	for _, userAndID := range foundUsers {
-		orgID := getUserOrgID(userAndID.user)
-		userResult := model.UserResult{
-			User:             userAndID.user,
-			OrganizationName: s.getOrgName(orgID),
-			TicketSubjects:   s.getTicketsForOrg(orgID),
-		}
+	err := s.find(s.entities["users"], q, func(key string, record map[string]interface{}, score float64) {
+		result = append(result, s.userResult(key, record, score))
+	})
 
-		result = append(result, userResult)
-	}
-
-	if len(result) < 1 {
-		return nil, ErrNotFound
-	}
-
-	return result, nil
+	return result, err
 }
 
-func findUserMatch(user model.User, term, value string) bool {
-	foundMatch := false
-
-	switch v := user[term].(type) {
-	case string:
-		foundMatch = v == value
-	case int:
-		foundMatch = strconv.Itoa(v) == value
-	case float64:
-		// assume there are no decimals
-		foundMatch = strconv.Itoa(int(v)) == value
-	case bool:
-		foundMatch = strconv.FormatBool(v) == value
-	case []interface{}:
-		s := ""
-		for _, elem := range v {
-			// assume they are strings and try to format them
-			// and append them to s
-			s = fmt.Sprintf("%s;%s", s, elem)
-		}
-
-		foundMatch = strings.Contains(s, value)
-	default:
-		fmt.Printf("unhandled type for v: %T\n", v)
-	}
-
-	return foundMatch
-}
-
-func (s *Storage) getOrgName(orgID model.OrgID) string {
-	org, ok := s.organizationsMap[orgID]
-	if !ok {
-		return ""
+// userResult fetches the name of the organization and the subjects of the tickets
+// submitted and assigned to the user
+func (s *Storage) userResult(key string, user map[string]interface{}, score float64) model.UserResult {
+	return model.UserResult{
+		User:             user,
+		OrganizationName: s.relatedString("users", "organization", key, "name"),
+		SubmittedTickets: s.relatedStrings("users", "submitted_tickets", key, "subject"),
+		AssignedTickets:  s.relatedStrings("users", "assigned_tickets", key, "subject"),
+		Score:            score,
 	}
-
-	// assume name is always a string
-	return org["name"].(string)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/jaimem88/zearch/internal/model"
+	"github.com/jaimem88/zearch/internal/query"
 )
 
 func TestStorage_Users(t *testing.T) {
@@ -62,7 +63,25 @@ func TestStorage_Users(t *testing.T) {
 				{
 					User:             userData[0],
 					OrganizationName: "Enthaze",
-					TicketSubjects: []string{
+					SubmittedTickets: []string{
+						"A Problem in Guyana",
+						"A Problem in Western Sahara",
+					},
+				},
+			},
+		},
+		{
+			name:       "search_by_id_with_assigned_ticket",
+			orgData:    orgData,
+			userData:   userData,
+			ticketData: ticketData,
+			term:       "_id",
+			value:      "2",
+			expectedResult: []*model.UserResult{
+				{
+					User:             userData[1],
+					OrganizationName: "Nutralab",
+					AssignedTickets: []string{
 						"A Problem in Guyana",
 					},
 				},
@@ -98,7 +117,7 @@ func TestStorage_Users(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(tt.orgData, tt.userData, tt.ticketData)
-			got, err := s.Users(tt.term, tt.value)
+			got, err := s.Users(query.Term{Field: tt.term, Value: tt.value})
 			if tt.expectedError != nil {
 				require.EqualError(t, err, tt.expectedError.Error())
 				return
@@ -115,7 +134,8 @@ func TestStorage_Users(t *testing.T) {
 			for k, expectedResult := range tt.expectedResult {
 				assert.Equal(t, expectedResult.User["_id"], got[k].User["_id"])
 				assert.Equal(t, expectedResult.OrganizationName, got[k].OrganizationName)
-				assert.ElementsMatch(t, expectedResult.TicketSubjects, got[k].TicketSubjects)
+				assert.ElementsMatch(t, expectedResult.SubmittedTickets, got[k].SubmittedTickets)
+				assert.ElementsMatch(t, expectedResult.AssignedTickets, got[k].AssignedTickets)
 			}
 		})
 	}
@@ -0,0 +1,129 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxDistance returns the edit distance allowed when fuzzy matching s. Short values
+// allow fewer typos so they don't match most of the other short values.
+func MaxDistance(s string) int {
+	switch n := len([]rune(s)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// FuzzyKeys returns the lowercase value and each of its words, which are the strings
+// a fuzzy search is compared with so "fransis" matches "Francis Bailey" and
+// "flotnic" matches "coffeyrasmussen@flotonic.com".
+func FuzzyKeys(value string) []string {
+	value = strings.ToLower(value)
+	keys := []string{value}
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if word != keys[len(keys)-1] {
+			keys = append(keys, word)
+		}
+	}
+
+	return keys
+}
+
+// Trigrams returns the distinct sequences of three runes of s padded with two
+// spaces at each end, so every rune is part of three trigrams. An edit changes at
+// most three of them and a transposition four, which bounds how many trigrams two
+// strings within a distance share.
+func Trigrams(s string) []string {
+	runes := []rune("  " + s + "  ")
+
+	seen := map[string]bool{}
+	grams := make([]string, 0, len(runes)-2)
+	for k := 0; k+3 <= len(runes); k++ {
+		gram := string(runes[k : k+3])
+		if seen[gram] {
+			continue
+		}
+
+		seen[gram] = true
+		grams = append(grams, gram)
+	}
+
+	return grams
+}
+
+// Distance returns the Damerau-Levenshtein distance between a and b, the number of
+// runes inserted, deleted, substituted or swapped with the next one to turn a into b.
+// Adjacent swaps are not edited again (optimal string alignment). The result is
+// max+1 when it is greater than max, which stops the comparison early.
+func Distance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > max {
+		return max + 1
+	}
+
+	// rows of the dynamic programming matrix, two rows up is needed for swaps
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d := minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d = minInt(d, prev2[j-2]+1)
+			}
+
+			curr[j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+
+		if rowMin > max {
+			return max + 1
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	if prev[len(rb)] > max {
+		return max + 1
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
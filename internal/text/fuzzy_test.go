@@ -0,0 +1,50 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		max      int
+		expected int
+	}{
+		{a: "francis", b: "francis", max: 2, expected: 0},
+		{a: "fransis", b: "francis", max: 2, expected: 1},
+		{a: "fracnis", b: "francis", max: 2, expected: 1},
+		{a: "frncs", b: "francis", max: 2, expected: 2},
+		{a: "frn", b: "francis", max: 2, expected: 3},
+		{a: "bailey", b: "barlow", max: 1, expected: 2},
+		{a: "bailey", b: "barlow", max: 5, expected: 3},
+		{a: "strezzo", b: "strezzö", max: 2, expected: 1},
+		{a: "", b: "ab", max: 2, expected: 2},
+		{a: "ca", b: "abc", max: 5, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Distance(tt.a, tt.b, tt.max))
+			assert.Equal(t, tt.expected, Distance(tt.b, tt.a, tt.max))
+		})
+	}
+}
+
+func TestMaxDistance(t *testing.T) {
+	assert.Equal(t, 0, MaxDistance("jo"))
+	assert.Equal(t, 1, MaxDistance("cross"))
+	assert.Equal(t, 2, MaxDistance("strezzö"))
+}
+
+func TestFuzzyKeys(t *testing.T) {
+	assert.Equal(t, []string{"francis"}, FuzzyKeys("Francis"))
+	assert.Equal(t, []string{"francis bailey", "francis", "bailey"}, FuzzyKeys("Francis Bailey"))
+	assert.Equal(t, []string{"coffeyrasmussen@flotonic.com", "coffeyrasmussen", "flotonic", "com"}, FuzzyKeys("coffeyrasmussen@flotonic.com"))
+}
+
+func TestTrigrams(t *testing.T) {
+	assert.Equal(t, []string{"  a", " ab", "ab ", "b  "}, Trigrams("ab"))
+	assert.Equal(t, []string{"  a", " aa", "aaa", "aa ", "a  "}, Trigrams("aaaa"))
+}
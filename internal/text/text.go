@@ -0,0 +1,158 @@
+// Package text splits free text into the tokens used by the full-text index, e.g.
+//
+//	"Printers are NOT working!" -> printer, work
+//
+// Words are split on anything that is not a letter or a number, lowercased and
+// common English stop words are removed. Stemming is optional.
+//
+// It also measures the edit distance between strings for fuzzy matching.
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Analyzer converts text into tokens. The same Analyzer must be used to index
+// the text and to analyze the queries searching it.
+type Analyzer struct {
+	// Stem reduces words to their stem so different forms of a word match
+	// each other e.g. printers and printer
+	Stem bool
+}
+
+// Tokens returns the tokens of s in the order they appear, including repeated tokens
+func (a Analyzer) Tokens(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if stopWords[word] {
+			continue
+		}
+
+		if a.Stem {
+			word = Stem(word)
+		}
+
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
+
+// Stem strips common English suffixes from a lowercase word. It is a light stemmer
+// that only handles plurals and the -ed and -ing forms, which is enough for words to
+// match their most common variations e.g. printers, printer and printing all become print.
+func Stem(word string) string {
+	// short words are usually stems already and runes are compared as bytes below
+	if len(word) <= 3 || !isASCII(word) {
+		return word
+	}
+
+	return stemEnding(stemVerb(stemPlural(word)))
+}
+
+// stemPlural turns plurals into singulars e.g. printers becomes printer
+func stemPlural(word string) string {
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+// stemVerb strips the -ing and -ed forms e.g. printing becomes print
+func stemVerb(word string) string {
+	for _, suffix := range []string{"ing", "ed"} {
+		if stem := strings.TrimSuffix(word, suffix); stem != word && len(stem) >= 3 && hasVowel(stem) {
+			return undouble(stem)
+		}
+	}
+
+	return word
+}
+
+// stemEnding strips a trailing -er or -e e.g. printer becomes print
+func stemEnding(word string) string {
+	if stem := strings.TrimSuffix(word, "er"); stem != word && len(stem) >= 4 {
+		return stem
+	}
+
+	if stem := strings.TrimSuffix(word, "e"); stem != word && len(stem) >= 3 {
+		return stem
+	}
+
+	return word
+}
+
+// undouble removes the last letter of words ending in a double consonant
+// e.g. runn from running becomes run
+func undouble(word string) string {
+	n := len(word)
+	if n < 2 || word[n-1] != word[n-2] || isVowel(word[n-1]) {
+		return word
+	}
+
+	switch word[n-1] {
+	case 'l', 's', 'z':
+		// e.g. install, pass, buzz
+		return word
+	}
+
+	return word[:n-1]
+}
+
+func hasVowel(word string) bool {
+	for k := 0; k < len(word); k++ {
+		if isVowel(word[k]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isVowel(c byte) bool {
+	switch c {
+	case 'a', 'e', 'i', 'o', 'u', 'y':
+		return true
+	default:
+		return false
+	}
+}
+
+func isASCII(word string) bool {
+	for k := 0; k < len(word); k++ {
+		if word[k] > unicode.MaxASCII {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stopWords are common English words that are not worth indexing
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "because": true,
+	"been": true, "but": true, "by": true, "can": true, "could": true, "d": true, "did": true,
+	"do": true, "does": true, "for": true, "from": true, "had": true, "has": true, "have": true,
+	"he": true, "her": true, "him": true, "his": true, "how": true, "i": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "ll": true, "m": true,
+	"me": true, "my": true, "no": true, "not": true, "of": true, "on": true, "or": true,
+	"our": true, "re": true, "s": true, "she": true, "so": true, "t": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "too": true, "us": true, "ve": true,
+	"was": true, "we": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "while": true, "who": true, "will": true, "with": true, "would": true,
+	"you": true, "your": true,
+}
@@ -0,0 +1,80 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzer_Tokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer Analyzer
+		input    string
+		expected []string
+	}{
+		{
+			name:     "lowercase_and_stop_words",
+			input:    "A Catastrophe in Korea (North)",
+			expected: []string{"catastrophe", "korea", "north"},
+		},
+		{
+			name:     "unicode_words",
+			input:    "Strezzö café—naïve, 東京!",
+			expected: []string{"strezzö", "café", "naïve", "東京"},
+		},
+		{
+			name:     "numbers",
+			input:    "ticket #1234 on 2016-04-28",
+			expected: []string{"ticket", "1234", "2016", "04", "28"},
+		},
+		{
+			name:     "repeated_words",
+			input:    "problem problem",
+			expected: []string{"problem", "problem"},
+		},
+		{
+			name:     "stem",
+			analyzer: Analyzer{Stem: true},
+			input:    "Printers are not printing the created issues",
+			expected: []string{"print", "print", "creat", "issu"},
+		},
+		{
+			name:     "only_stop_words",
+			input:    "it is what it is",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.analyzer.Tokens(tt.input))
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"print":     "print",
+		"printer":   "print",
+		"printers":  "print",
+		"printed":   "print",
+		"printing":  "print",
+		"running":   "run",
+		"installed": "install",
+		"addresses": "address",
+		"status":    "status",
+		"companies": "company",
+		"boxes":     "box",
+		"create":    "creat",
+		"creating":  "creat",
+		"user":      "user",
+		"users":     "user",
+		"bed":       "bed",
+		"strezzös":  "strezzös",
+	}
+
+	for word, expected := range tests {
+		assert.Equal(t, expected, Stem(word), word)
+	}
+}
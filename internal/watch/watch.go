@@ -0,0 +1,78 @@
+// Package watch detects changes to files by polling their size and modification time,
+// which works on every platform without depending on file system notifications.
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileState is what is compared to detect that a file changed
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (fs fileState) equal(other fileState) bool {
+	return fs.exists == other.exists && fs.size == other.size && fs.modTime.Equal(other.modTime)
+}
+
+// Poll checks the files every interval and calls onChange after any of them changes.
+// A change is only reported once the files stay the same for a whole interval, so a
+// file that is still being written is not read half way. Poll blocks until ctx is done.
+func Poll(ctx context.Context, files []string, interval time.Duration, onChange func()) {
+	// seen is the state onChange was last called for, or the initial state
+	seen := stat(files)
+	prev := seen
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := stat(files)
+		if !equal(current, prev) {
+			// still changing, wait until it settles
+			prev = current
+			continue
+		}
+
+		if !equal(current, seen) {
+			seen = current
+			onChange()
+		}
+	}
+}
+
+func stat(files []string) []fileState {
+	states := make([]fileState, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// a missing file is a state too, e.g. while it is being replaced
+			states = append(states, fileState{})
+			continue
+		}
+
+		states = append(states, fileState{exists: true, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	return states
+}
+
+func equal(a, b []fileState) bool {
+	for k := range a {
+		if !a[k].equal(b[k]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.json")
+	tickets := filepath.Join(dir, "tickets.json")
+	require.NoError(t, os.WriteFile(users, []byte("[]"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Poll(ctx, []string{users, tickets}, 10*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+
+	requireChange := func() {
+		t.Helper()
+
+		select {
+		case <-changes:
+		case <-time.After(time.Second):
+			t.Fatal("change not detected")
+		}
+	}
+
+	// let Poll read the initial state before changing it
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(users, []byte(`[{"_id": 1}]`), 0o600))
+	requireChange()
+
+	// files that are created are changes too
+	require.NoError(t, os.WriteFile(tickets, []byte("[]"), 0o600))
+	requireChange()
+
+	select {
+	case <-changes:
+		t.Fatal("unexpected change")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	<-done
+}